@@ -0,0 +1,145 @@
+package aggregate
+
+import (
+	"context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"reflect"
+)
+
+// Bus
+//
+// Publishes the domain events of the aggregates.
+type Bus interface {
+	Publish(ctx context.Context, event any) error
+}
+
+type BusFunc func(ctx context.Context, event any) error
+
+func (f BusFunc) Publish(ctx context.Context, event any) error {
+	return f(ctx, event)
+}
+
+// DefaultBus
+//
+// Publishes the events into the running `eventx` application. (See eventx.TriggerAny)
+var DefaultBus Bus = BusFunc(func(ctx context.Context, event any) error {
+	_, err := eventx.TriggerAny(ctx, event, entity.Metadata{})
+	return err
+})
+
+// AggregateRoot
+//
+// Embeddable into the aggregates to collect their domain events.
+//
+// The state is changed only by applying events through the functions registered with On:
+// Raise applies a new event and keeps it pending, Rehydrate applies the events of the history.
+// An AggregateRoot is not safe for concurrent use.
+type AggregateRoot struct {
+	version int64
+	pending []any
+	// saved is the number of the pending events which have been saved (see MarkSaved)
+	saved    int
+	appliers map[reflect.Type]func(event any)
+}
+
+// On
+//
+// Registers the function applying the events of type E to the state of the aggregate.
+// The events without an apply function are recorded without changing the state.
+func On[E any](root *AggregateRoot, apply func(event E)) {
+	if root.appliers == nil {
+		root.appliers = map[reflect.Type]func(event any){}
+	}
+
+	root.appliers[reflect.TypeOf((*E)(nil)).Elem()] = func(event any) {
+		apply(event.(E))
+	}
+}
+
+// Raise
+//
+// Applies the new event and records it as pending.
+func (a *AggregateRoot) Raise(event any) {
+	a.apply(event)
+	a.pending = append(a.pending, event)
+}
+
+// Rehydrate
+//
+// Rebuilds the state by applying the events of the history. Every event increments the version.
+func (a *AggregateRoot) Rehydrate(events ...any) {
+	for _, event := range events {
+		a.apply(event)
+		a.version += 1
+	}
+}
+
+// Version
+//
+// Returns the number of the saved events of the aggregate: the rehydrated ones and the ones marked saved.
+// It can be used as the expected version of an optimistic concurrency check.
+func (a *AggregateRoot) Version() int64 {
+	return a.version
+}
+
+// Pending
+//
+// Returns the events raised since the last publication.
+func (a *AggregateRoot) Pending() []any {
+	return append([]any(nil), a.pending...)
+}
+
+// MarkSaved
+//
+// Advances the version over the pending events after they have been saved. They stay pending until they are published.
+func (a *AggregateRoot) MarkSaved() {
+	a.version += int64(len(a.pending) - a.saved)
+	a.saved = len(a.pending)
+}
+
+// ClearPending
+//
+// Marks the pending events saved and forgets them (e.g. after they have been saved to an event store publishing them).
+func (a *AggregateRoot) ClearPending() {
+	a.MarkSaved()
+	a.pending = nil
+	a.saved = 0
+}
+
+// PublishPending
+//
+// Publishes the pending events in order. It should be called after the aggregate has been saved successfully and MarkSaved.
+//
+// If an event cannot be published, the publication stops and the error is returned;
+// the events which have not been published stay pending. The events without a listener count as published.
+// The publication does not change the version.
+func (a *AggregateRoot) PublishPending(ctx context.Context, bus Bus) error {
+	if bus == nil {
+		bus = DefaultBus
+	}
+
+	for len(a.pending) > 0 {
+		err := bus.Publish(ctx, a.pending[0])
+		if err != nil && !stderrors.Is(err, errors.NotFoundEventListenerErr) {
+			return err
+		}
+
+		a.pending = a.pending[1:]
+		if a.saved > 0 {
+			a.saved -= 1
+		}
+	}
+	a.pending = nil
+	a.saved = 0
+
+	return nil
+}
+
+func (a *AggregateRoot) apply(event any) {
+	if apply, ok := a.appliers[reflect.TypeOf(event)]; ok {
+		apply(event)
+	}
+}
@@ -0,0 +1,206 @@
+package backfill
+
+import (
+	"context"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/projection"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultBatchSize = 100
+
+type Options struct {
+	// Name identifies the checkpoint of the backfill.
+	Name string
+	// Checkpoints makes the backfill resumable: it starts after the saved position, which is updated after every batch.
+	// The backfill starts from the beginning when it is nil.
+	Checkpoints projection.CheckpointStore
+	// Rate limits the historical events handed to the listener per second. 0 is unlimited.
+	Rate float64
+	// BatchSize is the number of the events read at once. It is DefaultBatchSize when it is 0.
+	BatchSize int
+	// OnProgress receives the progress after every batch.
+	OnProgress func(progress Progress)
+	// OnError receives the errors of the listener. A failed event does not stop the backfill.
+	OnError func(err error)
+}
+
+// Progress
+//
+// Position is the position of the last replayed event, and Head the position the backfill ends at.
+// Replayed counts the events handed to the listener, Skipped the events of the other types and those received live.
+type Progress struct {
+	Position int64
+	Head     int64
+	Replayed int64
+	Skipped  int64
+	Failed   int64
+}
+
+// Run
+//
+// Replays the historical events of type E of the source into the target only, up to the last event at the start.
+// The target keeps receiving the live events meanwhile (see Target).
+//
+// errors.AlreadyRegisteredErr is returned if a backfill of the target is already running.
+// If ctx is canceled, the backfill stops at the last saved checkpoint and can be resumed;
+// the target holds the deferred live events until then. (See Target.Release)
+func Run[E any](ctx context.Context, target *Target[E], source Source, options Options) (Progress, error) {
+	if target == nil || source == nil || options.BatchSize < 0 || options.Rate < 0 {
+		return Progress{}, errors.InvalidConfigurationErr
+	}
+	if options.BatchSize == 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	if options.OnProgress == nil {
+		options.OnProgress = func(Progress) {}
+	}
+	if options.OnError == nil {
+		options.OnError = func(error) {}
+	}
+
+	var failed atomic.Int64
+	onError := func(err error) {
+		failed.Add(1)
+		options.OnError(err)
+	}
+	if !target.begin(onError) {
+		return Progress{}, errors.AlreadyRegisteredErr
+	}
+	completed := false
+	defer func() {
+		if completed {
+			target.end()
+		} else {
+			// the deferred events wait for the rest of the history
+			target.suspend()
+		}
+	}()
+
+	var progress Progress
+	if options.Checkpoints != nil {
+		position, err := options.Checkpoints.Load(ctx, options.Name)
+		if err != nil {
+			return progress, err
+		}
+		progress.Position = position
+	}
+
+	// the keys of the historical events and the position of their last event
+	lastPositions, head, err := scan(ctx, target, source, progress.Position, options.BatchSize)
+	if err != nil {
+		return progress, err
+	}
+	progress.Head = head
+	keys := map[string]bool{}
+	for key := range lastPositions {
+		keys[key] = true
+	}
+	target.scanned(keys)
+
+	var interval time.Duration
+	if options.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / options.Rate)
+	}
+	next := time.Now()
+
+	for progress.Position < head {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		records, err := source.Read(ctx, progress.Position+1, options.BatchSize)
+		if err != nil {
+			return progress, err
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		for _, record := range records {
+			if record.Position > head {
+				break
+			}
+
+			e, ok := record.Event.(E)
+			if !ok || target.isDeferred(record.Metadata.ID) {
+				progress.Skipped += 1
+			} else {
+				if interval > 0 {
+					if err := wait(ctx, next); err != nil {
+						return progress, err
+					}
+					next = time.Now().Add(interval)
+				}
+
+				target.deliver(context.Background(), entity.Envelope[E]{Metadata: record.Metadata, Entity: e})
+				progress.Replayed += 1
+			}
+			progress.Position = record.Position
+
+			if ok {
+				key := target.key(entity.Envelope[E]{Metadata: record.Metadata, Entity: e})
+				if key != "" && lastPositions[key] == record.Position {
+					target.release(key)
+				}
+			}
+		}
+
+		progress.Failed = failed.Load()
+		if options.Checkpoints != nil {
+			if err := options.Checkpoints.Save(ctx, options.Name, progress.Position); err != nil {
+				return progress, err
+			}
+		}
+		options.OnProgress(progress)
+	}
+	progress.Failed = failed.Load()
+	completed = true
+
+	return progress, nil
+}
+
+// scan
+//
+// Reads the source from the position and returns the position of the last event of each key, and the last position.
+func scan[E any](ctx context.Context, target *Target[E], source Source, from int64, batchSize int) (map[string]int64, int64, error) {
+	lastPositions := map[string]int64{}
+	head := from
+	for {
+		records, err := source.Read(ctx, head+1, batchSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(records) == 0 {
+			return lastPositions, head, nil
+		}
+
+		for _, record := range records {
+			head = record.Position
+			if e, ok := record.Event.(E); ok {
+				if key := target.key(entity.Envelope[E]{Metadata: record.Metadata, Entity: e}); key != "" {
+					lastPositions[key] = record.Position
+				}
+			}
+		}
+	}
+}
+
+func wait(ctx context.Context, until time.Time) error {
+	delay := time.Until(until)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
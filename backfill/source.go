@@ -0,0 +1,204 @@
+package backfill
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/eventstore"
+	"github.com/aivyss/eventx/wal"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Record
+//
+// A historical event. Position is its position in the source: it is 1-based, and increases with the records.
+// Event is nil if the source cannot decode it (e.g. its type is not registered).
+type Record struct {
+	Position int64
+	Event    any
+	Metadata entity.Metadata
+}
+
+// Source
+//
+// The historical events. Read returns up to limit records from the position `from` (inclusive) in order.
+type Source interface {
+	Read(ctx context.Context, from int64, limit int) ([]Record, error)
+}
+
+// EventStoreSource
+//
+// Reads the global stream of the event store.
+func EventStoreSource(store *eventstore.Store) Source {
+	return eventStoreSource{store: store}
+}
+
+type eventStoreSource struct {
+	store *eventstore.Store
+}
+
+func (s eventStoreSource) Read(ctx context.Context, from int64, limit int) ([]Record, error) {
+	recorded, err := s.store.ReadAll(ctx, from, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(recorded))
+	for _, r := range recorded {
+		event, _ := s.store.Decode(r)
+		records = append(records, Record{Position: r.Position, Event: event, Metadata: r.Metadata})
+	}
+
+	return records, nil
+}
+
+// WALSource
+//
+// Reads the entries kept by the write-ahead log (the incomplete ones), in the order they have been appended.
+// The position of an entry is its wal.Entry.Sequence, which does not change when the earlier entries are completed.
+// The source reads a snapshot of the entries, so the sequential reads do not rescan the log;
+// the snapshot is taken again when a read goes back (a new pass) or reaches its end (to see the entries appended since).
+func WALSource(log *wal.Log) Source {
+	return &walSource{log: log}
+}
+
+type walSource struct {
+	log  *wal.Log
+	lock sync.Mutex
+	// entries is the snapshot sorted by sequence, and from the position of the last read.
+	entries []wal.Entry
+	from    int64
+}
+
+func (s *walSource) Read(_ context.Context, from int64, limit int) ([]Record, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.entries == nil || from <= s.from {
+		s.entries = s.log.Incomplete()
+	}
+	s.from = from
+
+	i := s.search(from)
+	if i == len(s.entries) {
+		s.entries = s.log.Incomplete()
+		i = s.search(from)
+	}
+
+	var records []Record
+	for _, entry := range s.entries[i:] {
+		if len(records) == limit {
+			break
+		}
+
+		event, _ := s.log.Codec().Decode(entry.TypeName, entry.Payload)
+		records = append(records, Record{Position: int64(entry.Sequence), Event: event, Metadata: entry.Metadata})
+	}
+
+	return records, nil
+}
+
+// search
+//
+// Returns the index of the first entry of the snapshot at the position or after it.
+func (s *walSource) search(position int64) int {
+	return sort.Search(len(s.entries), func(i int) bool {
+		return int64(s.entries[i].Sequence) >= position
+	})
+}
+
+// fileLine
+//
+// A line of a recorded file (JSON Lines).
+type fileLine struct {
+	Type     string          `json:"type"`
+	Payload  []byte          `json:"payload"`
+	Metadata entity.Metadata `json:"metadata"`
+}
+
+// WriteRecord
+//
+// Appends the event to a recorded file read by FileSource.
+func WriteRecord(w io.Writer, registry *codec.Registry, event any, metadata entity.Metadata) error {
+	typeName, payload, err := registry.Encode(event)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(fileLine{Type: typeName, Payload: payload, Metadata: metadata})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+
+	return err
+}
+
+// FileSource
+//
+// Reads a recorded file (see WriteRecord). The position of an event is its line number.
+// The source keeps the offset of the last line it has read, so the sequential reads do not rescan the file;
+// a line which is not terminated yet (being written) is not read.
+func FileSource(path string, registry *codec.Registry) Source {
+	return &fileSource{path: path, registry: registry}
+}
+
+type fileSource struct {
+	path     string
+	registry *codec.Registry
+	lock     sync.Mutex
+	// position is the line number of the last line read, and offset the offset of the next line.
+	position int64
+	offset   int64
+}
+
+func (s *fileSource) Read(_ context.Context, from int64, limit int) ([]Record, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	position, offset := int64(0), int64(0)
+	if from > s.position {
+		position, offset = s.position, s.offset
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	reader := bufio.NewReader(file)
+	for len(records) < limit {
+		content, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		position += 1
+		offset += int64(len(content))
+		s.position, s.offset = position, offset
+		if position < from {
+			continue
+		}
+
+		var line fileLine
+		if err := json.Unmarshal(content, &line); err != nil {
+			return nil, err
+		}
+		event, _ := s.registry.Decode(line.Type, line.Payload)
+		records = append(records, Record{Position: position, Event: event, Metadata: line.Metadata})
+	}
+
+	return records, nil
+}
@@ -0,0 +1,376 @@
+package backfill
+
+import (
+	"context"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"runtime/debug"
+	"sync"
+)
+
+// KeyFunc
+//
+// Extracts the ordering key of an event. The events of a key are handed to the listener in order;
+// the events with a blank key are not ordered.
+type KeyFunc[E any] func(envelope entity.Envelope[E]) string
+
+// Target
+//
+// Wraps the listener to be backfilled. Register the Target (see Register) instead of the listener, then run the backfill.
+//
+// While a backfill is running, the live events of the keys which still have historical events to replay are deferred:
+// they are handed to the listener by the backfill right after the last historical event of their key,
+// so the listener sees the events of a key in order.
+// If the backfill stops before the end of the history, the deferred events are held until a backfill resumes it. (See Release)
+type Target[E any] struct {
+	listener entity.EventListener[E]
+	key      KeyFunc[E]
+	lock     sync.Mutex
+	// active is set from the start of a backfill until the end of its history.
+	active bool
+	// running is set while a backfill is running.
+	running bool
+	// scanning is set until the keys of the historical events are known; every keyed live event is deferred meanwhile.
+	scanning bool
+	// pending holds the keys which still have historical events to replay or deferred events to hand over.
+	pending map[string]bool
+	// deferred holds the deferred live events by key, and deferredIDs their IDs.
+	deferred    map[string][]deferredEvent[E]
+	deferredIDs map[string]bool
+	onError     func(err error)
+}
+
+type deferredEvent[E any] struct {
+	ctx      context.Context
+	envelope entity.Envelope[E]
+	// set is the event set of a target registered with Register, which is resumed once the event has been handed over.
+	set    *targetEventSet[E]
+	resume func(runner entity.EventRunner)
+}
+
+// acceptedKey
+//
+// Marks the context of a live event which the target has not deferred.
+type acceptedKey struct{}
+
+func NewTarget[E any](listener entity.EventListener[E], key KeyFunc[E]) *Target[E] {
+	if key == nil {
+		key = func(entity.Envelope[E]) string { return "" }
+	}
+
+	return &Target[E]{listener: listener, key: key}
+}
+
+func (t *Target[E]) Trigger(e E) error {
+	return t.TriggerEnvelope(context.Background(), entity.Envelope[E]{Entity: e})
+}
+
+// TriggerEnvelope
+//
+// Runs the listener for a live event. If the target has been registered without Register,
+// a deferred event is reported skipped (see entity.SkipEvent) and handed to the listener later.
+func (t *Target[E]) TriggerEnvelope(ctx context.Context, envelope entity.Envelope[E]) error {
+	if ctx.Value(acceptedKey{}) == nil && t.hold(deferredEvent[E]{ctx: context.WithoutCancel(ctx), envelope: envelope}) {
+		entity.SkipEvent(ctx)
+		return nil
+	}
+
+	return t.trigger(ctx, envelope)
+}
+
+func (t *Target[E]) Then(e E) {
+	if listener, ok := t.listener.(entity.SuccessEventListener[E]); ok {
+		listener.Then(e)
+	}
+}
+
+func (t *Target[E]) Catch(err error) {
+	if listener, ok := t.listener.(entity.CatchErrEventListener[E]); ok {
+		listener.Catch(err)
+	}
+}
+
+// Register
+//
+// Registers the target into `eventx`. (See eventx.RegisterEventListener)
+// The deferred live events then stay incomplete until they have been handed to the listener,
+// instead of being reported skipped.
+func (t *Target[E]) Register() error {
+	return eventx.RegisterEventListener[E](t, t.deferring)
+}
+
+// Release
+//
+// Hands the held live events over and stops deferring, when a stopped backfill is not resumed.
+// It returns false if a backfill is running.
+func (t *Target[E]) Release() bool {
+	t.lock.Lock()
+	running := t.running
+	t.lock.Unlock()
+	if running {
+		return false
+	}
+
+	t.end()
+	return true
+}
+
+// hold
+//
+// Defers the live event if a backfill is active and its key still has historical events to replay.
+func (t *Target[E]) hold(event deferredEvent[E]) bool {
+	key := t.key(event.envelope)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.active || key == "" || !(t.scanning || t.pending[key]) {
+		return false
+	}
+	t.deferred[key] = append(t.deferred[key], event)
+	t.deferredIDs[event.envelope.ID] = true
+
+	return true
+}
+
+// begin
+//
+// Starts deferring the keyed live events until the keys of the historical events are known.
+// The events held by a stopped backfill stay deferred.
+func (t *Target[E]) begin(onError func(err error)) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.running {
+		return false
+	}
+	t.running = true
+	t.scanning = true
+	t.onError = onError
+	if !t.active {
+		t.active = true
+		t.pending = map[string]bool{}
+		t.deferred = map[string][]deferredEvent[E]{}
+		t.deferredIDs = map[string]bool{}
+	}
+
+	return true
+}
+
+// scanned
+//
+// Sets the keys which have historical events to replay, and hands over the deferred events of the other keys.
+func (t *Target[E]) scanned(keys map[string]bool) {
+	t.lock.Lock()
+	t.scanning = false
+	var released []string
+	for key := range t.deferred {
+		released = append(released, key)
+	}
+	t.pending = map[string]bool{}
+	for key := range keys {
+		t.pending[key] = true
+	}
+	for _, key := range released {
+		t.pending[key] = true
+	}
+	t.lock.Unlock()
+
+	for _, key := range released {
+		if !keys[key] {
+			t.release(key)
+		}
+	}
+}
+
+// isDeferred
+//
+// Reports whether the historical event has also been received live and deferred; it is then handed over with its key.
+func (t *Target[E]) isDeferred(id string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.deferredIDs[id]
+}
+
+// release
+//
+// Hands over the deferred events of the key in order. The key stays pending until they are all handed over,
+// so the live events received meanwhile are deferred behind them.
+func (t *Target[E]) release(key string) {
+	for {
+		t.lock.Lock()
+		events := t.deferred[key]
+		if len(events) == 0 {
+			delete(t.deferred, key)
+			delete(t.pending, key)
+			t.lock.Unlock()
+			return
+		}
+		delete(t.deferred, key)
+		t.lock.Unlock()
+
+		for _, event := range events {
+			if event.set == nil {
+				t.deliver(event.ctx, event.envelope)
+				continue
+			}
+
+			afterRunner := event.set.run(event.ctx)
+			if err := event.set.Context().Err(); err != nil {
+				t.onError(err)
+			}
+			event.resume(func() func() {
+				return afterRunner
+			})
+		}
+	}
+}
+
+// suspend
+//
+// Ends a backfill stopped before the end of its history:
+// the deferred events of the keys which still have historical events to replay are held for the next backfill.
+func (t *Target[E]) suspend() {
+	t.lock.Lock()
+	t.running = false
+	scanning := t.scanning
+	keys := map[string]bool{}
+	for key := range t.pending {
+		keys[key] = true
+	}
+	t.lock.Unlock()
+
+	if scanning {
+		t.scanned(keys)
+	}
+}
+
+// end
+//
+// Hands over the remaining deferred events and stops deferring.
+func (t *Target[E]) end() {
+	for {
+		t.lock.Lock()
+		var key string
+		for k := range t.pending {
+			key = k
+			break
+		}
+		if key == "" {
+			t.active = false
+			t.running = false
+			t.pending = nil
+			t.deferred = nil
+			t.deferredIDs = nil
+			t.lock.Unlock()
+			return
+		}
+		t.lock.Unlock()
+
+		t.release(key)
+	}
+}
+
+// deliver
+//
+// Runs the listener and its callbacks for a historical or deferred event.
+// A panic of a callback is reported to onError.
+func (t *Target[E]) deliver(ctx context.Context, envelope entity.Envelope[E]) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.onError(&entity.PanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+
+	if err := t.trigger(ctx, envelope); err != nil {
+		t.onError(err)
+		t.Catch(err)
+		return
+	}
+	t.Then(envelope.Entity)
+}
+
+// trigger
+//
+// Runs the listener. A panic of the listener is returned as *entity.PanicError.
+func (t *Target[E]) trigger(ctx context.Context, envelope entity.Envelope[E]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &entity.PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	ctx = entity.ContextWithMetadata(ctx, envelope.Metadata)
+
+	switch listener := t.listener.(type) {
+	case entity.EnvelopeEventListener[E]:
+		return listener.TriggerEnvelope(ctx, envelope)
+	case entity.ContextEventListener[E]:
+		return listener.TriggerContext(ctx, envelope.Entity)
+	default:
+		return listener.Trigger(envelope.Entity)
+	}
+}
+
+// deferring
+//
+// The operator of Register: the event sets of the target are deferred by the target instead of being skipped.
+func (t *Target[E]) deferring(factory entity.EventSetFactory) entity.EventSetFactory {
+	return func(e any, metadata entity.Metadata) (entity.EventSet, bool) {
+		inner, ok := factory(e, metadata)
+		if !ok {
+			return nil, false
+		}
+
+		return &targetEventSet[E]{
+			target:   t,
+			inner:    inner,
+			envelope: entity.Envelope[E]{Metadata: metadata, Entity: e.(E)},
+		}, true
+	}
+}
+
+// targetEventSet
+//
+// An entity.DeferredEventSet of a registered target, which stays incomplete while the target defers it.
+type targetEventSet[E any] struct {
+	target   *Target[E]
+	inner    entity.EventSet
+	envelope entity.Envelope[E]
+}
+
+func (s *targetEventSet[E]) Runner() func() {
+	return s.RunnerContext(context.Background())
+}
+
+func (s *targetEventSet[E]) RunnerContext(ctx context.Context) func() {
+	if contextual, ok := s.inner.(entity.ContextualEventSet); ok {
+		return contextual.RunnerContext(ctx)
+	}
+
+	return s.inner.Runner()
+}
+
+func (s *targetEventSet[E]) RunnerDeferred(ctx context.Context, resume func(runner entity.EventRunner)) {
+	event := deferredEvent[E]{ctx: context.WithoutCancel(ctx), envelope: s.envelope, set: s, resume: resume}
+	if s.target.hold(event) {
+		return
+	}
+
+	afterRunner := s.run(ctx)
+	resume(func() func() {
+		return afterRunner
+	})
+}
+
+func (s *targetEventSet[E]) Context() *entity.EventRunnerContextImpl {
+	return s.inner.Context()
+}
+
+// run
+//
+// Executes the wrapped event set, which is not deferred again, and returns its after runner.
+func (s *targetEventSet[E]) run(ctx context.Context) func() {
+	return s.RunnerContext(context.WithValue(ctx, acceptedKey{}, true))
+}
@@ -1,37 +1,265 @@
 package eventx
 
 import (
+	gocontext "context"
+	stderrors "errors"
+	"fmt"
+	"github.com/aivyss/eventx/codec"
 	"github.com/aivyss/eventx/context"
 	"github.com/aivyss/eventx/entity"
 	"github.com/aivyss/eventx/errors"
 	"reflect"
+	"time"
 )
 
-func RunDefaultApplication() {
-	RunApplication(context.DefaultEventChannelBufferSize, context.DefaultEventProcessPoolSize, context.DefaultMultiEventMode)
+func RunDefaultApplication() error {
+	return RunApplication(context.DefaultEventChannelBufferSize, context.DefaultEventProcessPoolSize, context.DefaultMultiEventMode)
 }
 
-func RunApplication(eventChannelBufferSize int, eventProcessPoolSize int, multiEventMode bool) {
-	if appContext != nil {
-		appContext.Close()
+// RunApplication
+//
+// Creates and runs the application context, closing the previous one.
+//
+// The event listeners buffered while no application was running are registered to it. Their registration errors are returned
+// joined (e.g. errors.AlreadyRegisteredErr for a second listener of a type in single event mode); the application runs anyway.
+func RunApplication(eventChannelBufferSize int, eventProcessPoolSize int, multiEventMode bool) error {
+	if prev := currentApplicationContext(); prev != nil {
+		prev.Close()
 	}
 
+	appContextLock.Lock()
+	defer appContextLock.Unlock()
+
 	appContext = context.NewApplicationContext(eventChannelBufferSize, eventProcessPoolSize, multiEventMode)
+	appContext.SetLogger(logger)
+	appContext.SetSlowListenerThreshold(slowListenerThreshold)
+	appContext.SetTracer(tracer)
+	var errs []error
+	for _, pending := range pendingEventListeners {
+		// in single event mode, the first buffered listener of a type wins
+		if _, err := appContext.RegisterEventListenerIfAbsent(pending.typeVal, pending.listener, pending.factory); err != nil {
+			errs = append(errs, fmt.Errorf("buffered event listener of %s: %w", pending.typeVal, err))
+		}
+	}
+	pendingEventListeners = nil
+
 	appContext.ConsumeEventRunner()
+
+	return stderrors.Join(errs...)
 }
 
-func RegisterEventListener[E any](el entity.EventListener[E]) error {
+// SetLogger
+//
+// Sets the Logger of the current and the following applications.
+// *slog.Logger can be passed as it is. A nil logger silences `eventx`, which is the default.
+func SetLogger(l context.Logger) {
+	if l == nil {
+		l = context.NopLogger{}
+	}
+
+	appContextLock.Lock()
+	defer appContextLock.Unlock()
+
+	logger = l
+	if appContext != nil {
+		appContext.SetLogger(l)
+	}
+}
+
+// SetSlowListenerThreshold
+//
+// Event listener executions taking longer than the threshold are logged as slow handlers.
+// 0 (default) disables the slow handler records.
+func SetSlowListenerThreshold(threshold time.Duration) {
+	appContextLock.Lock()
+	defer appContextLock.Unlock()
+
+	slowListenerThreshold = threshold
+	if appContext != nil {
+		appContext.SetSlowListenerThreshold(threshold)
+	}
+}
+
+// SetTracer
+//
+// Sets the Tracer of the current and the following applications.
+// A nil tracer disables tracing, which is the default.
+func SetTracer(t context.Tracer) {
+	appContextLock.Lock()
+	defer appContextLock.Unlock()
+
+	tracer = t
+	if appContext != nil {
+		appContext.SetTracer(t)
+	}
+}
+
+// Reconfigure
+//
+// Changes the buffer size of the event channels, the event process pool size and the dispense pool size
+// of the running application without losing the registered event listeners and the queued events.
+func Reconfigure(eventChannelBufferSize int, eventProcessPoolSize int, dispensePoolSize int) error {
+	ctx := currentApplicationContext()
+	if ctx == nil {
+		return errors.ApplicationNotRunningErr
+	}
+
+	return ctx.Reconfigure(eventChannelBufferSize, eventProcessPoolSize, dispensePoolSize)
+}
+
+// EnableAutoscale
+//
+// Turns on the adaptive mode of the event process pool of the running application.
+//
+// Every scaling decision is also triggered as a context.ScalingDecision event,
+// so it can be observed by registering an event listener of context.ScalingDecision.
+func EnableAutoscale(config context.AutoscaleConfig) error {
+	ctx := currentApplicationContext()
+	if ctx == nil {
+		return errors.ApplicationNotRunningErr
+	}
+
+	onScale := config.OnScale
+	config.OnScale = func(decision context.ScalingDecision) {
+		if onScale != nil {
+			onScale(decision)
+		}
+		// the queues may be full when scaling up, so the autoscaler must not wait for it
+		go func() {
+			_, _ = Trigger(decision)
+		}()
+	}
+
+	return ctx.EnableAutoscale(config)
+}
+
+// DisableAutoscale
+//
+// Turns off the adaptive mode. The event process pool keeps its current size.
+func DisableAutoscale() {
+	if ctx := currentApplicationContext(); ctx != nil {
+		ctx.DisableAutoscale()
+	}
+}
+
+// AutoscaleStats
+//
+// Returns a snapshot of the adaptive mode of the running application.
+func AutoscaleStats() context.AutoscaleStats {
+	ctx := currentApplicationContext()
+	if ctx == nil {
+		return context.AutoscaleStats{}
+	}
+
+	return ctx.AutoscaleStats()
+}
+
+// Stats
+//
+// Returns a snapshot of the runtime statistics of the current application.
+// The zero value is returned if no application has been run yet.
+func Stats() context.Stats {
+	ctx := currentApplicationContext()
+	if ctx == nil {
+		return context.Stats{}
+	}
+
+	return ctx.Stats()
+}
+
+// Status
+//
+// Returns the lifecycle state of the current application.
+// context.NotStarted is returned if no application has been run yet.
+func Status() context.ApplicationStatus {
+	ctx := currentApplicationContext()
+	if ctx == nil {
+		return context.NotStarted
+	}
+
+	return ctx.Status()
+}
+
+func currentApplicationContext() *context.ApplicationContext {
+	appContextLock.RLock()
+	defer appContextLock.RUnlock()
+
+	return appContext
+}
+
+// bufferingEventListeners
+//
+// Returns whether the event listeners are buffered for the next RunApplication,
+// since no application is running or the current one is closing. appContextLock must be held.
+func bufferingEventListeners() bool {
+	if appContext == nil {
+		return true
+	}
+
+	switch appContext.Status() {
+	case context.Draining, context.Closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// RegisterEventListener
+//
+// Registers the event listener of the events of type E.
+// The operators (e.g. entity.Debounce, entity.Throttle and entity.Coalesce) change how its events are executed, in order.
+func RegisterEventListener[E any](el entity.EventListener[E], operators ...entity.Operator[E]) error {
 	var e E
-	typeVal := reflect.TypeOf(e)
 
-	listeners := appContext.GetEventListener(typeVal)
-	if !appContext.IsMultiMode() && len(listeners) > 0 {
-		return errors.AlreadyRegisteredErr
+	factory := entity.NewEventSetFactory(el)
+	for _, operator := range operators {
+		factory = operator(factory)
 	}
 
-	appContext.RegisterEventListener(typeVal, el)
+	return registerEventListener(reflect.TypeOf(e), el, factory)
+}
 
-	return nil
+// RegisterBatchEventListener
+//
+// Registers an event listener processing the events of type E in bulk. (See entity.BatchEventListener)
+// Every event keeps its own entity.EventContext, which is completed when its batch has been processed.
+func RegisterBatchEventListener[E any](el entity.BatchEventListener[E], options entity.BatchOptions) error {
+	if el == nil {
+		return errors.NoTriggerFuncErr
+	}
+	var e E
+
+	return registerEventListener(reflect.TypeOf(e), el, entity.NewBatchEventSetFactory(el, options))
+}
+
+// RegisterFuncAsBatchEventListener
+//
+// Works like RegisterBatchEventListener with a function.
+func RegisterFuncAsBatchEventListener[E any](trigger func(entities []E) error, options entity.BatchOptions) error {
+	if trigger == nil {
+		return errors.NoTriggerFuncErr
+	}
+
+	return RegisterBatchEventListener[E](entity.TriggerBatchFunc[E](trigger), options)
+}
+
+func registerEventListener(typeVal reflect.Type, el any, factory entity.EventSetFactory) error {
+	appContextLock.Lock()
+	defer appContextLock.Unlock()
+
+	if bufferingEventListeners() {
+		// buffered until the next RunApplication
+		pendingEventListeners = append(pendingEventListeners, pendingEventListener{
+			typeVal:  typeVal,
+			listener: el,
+			factory:  factory,
+		})
+
+		return nil
+	}
+
+	_, err := appContext.RegisterEventListenerIfAbsent(typeVal, el, factory)
+	return err
 }
 
 func RegisterFuncAsEventListener[E any](trigger func(entity E) error) error {
@@ -42,6 +270,30 @@ func RegisterFuncAsEventListener[E any](trigger func(entity E) error) error {
 	return RegisterEventListener(entity.BuildEventListener(trigger))
 }
 
+// RegisterFuncContextAsEventListener
+//
+// Works like RegisterFuncAsEventListener, and the trigger receives the context.Context of the execution.
+// (See TriggerContext)
+func RegisterFuncContextAsEventListener[E any](trigger func(ctx gocontext.Context, entity E) error) error {
+	if trigger == nil {
+		return errors.NoTriggerFuncErr
+	}
+
+	return RegisterEventListener(entity.BuildContextEventListener(trigger))
+}
+
+// RegisterFuncEnvelopeAsEventListener
+//
+// Works like RegisterFuncContextAsEventListener, and the trigger receives the entity wrapped with its metadata.
+// (See TriggerEnvelope)
+func RegisterFuncEnvelopeAsEventListener[E any](trigger func(ctx gocontext.Context, envelope entity.Envelope[E]) error) error {
+	if trigger == nil {
+		return errors.NoTriggerFuncErr
+	}
+
+	return RegisterEventListener(entity.BuildEnvelopeEventListener(trigger))
+}
+
 func RegisterFuncThenAsEventListener[E any](
 	trigger func(entity E) error,
 	then func(entity E),
@@ -96,26 +348,166 @@ func RegisterFuncsAsEventListener[E any](
 }
 
 func Close() {
-	appContext.Close()
+	if ctx := currentApplicationContext(); ctx != nil {
+		ctx.Close()
+	}
+}
+
+// Closing
+//
+// Returns the channel which is closed once the running application starts closing. (See context.ApplicationContext.Closing)
+// It is nil, which never closes, if no application is running or draining.
+func Closing() <-chan struct{} {
+	if app := currentApplicationContext(); app != nil && app.Status() != context.Closed {
+		return app.Closing()
+	}
+
+	return nil
+}
+
+// CloseContext
+//
+// Works like Close, and the drain of the queued events is bounded by ctx instead of context.DefaultCloseTimeout.
+// The error of ctx is returned if the drain has been interrupted. (See context.ApplicationContext.CloseContext)
+func CloseContext(ctx gocontext.Context) error {
+	if app := currentApplicationContext(); app != nil {
+		return app.CloseContext(ctx)
+	}
+
+	return nil
 }
 
 func Trigger[E any](elem E) ([]entity.EventContext, error) {
+	return TriggerContext(gocontext.Background(), elem)
+}
+
+// TriggerContext
+//
+// Works like Trigger, and the values of triggerContext (e.g. the span) are carried across the asynchronous hops:
+// they are passed to the Tracer and to the event listeners implementing entity.ContextEventListener.
+// The cancellation of triggerContext does not affect the events.
+func TriggerContext[E any](triggerContext gocontext.Context, elem E) ([]entity.EventContext, error) {
+	return TriggerEnvelope(triggerContext, entity.Envelope[E]{Entity: elem})
+}
+
+// TriggerEnvelope
+//
+// Works like TriggerContext with the metadata of the envelope (e.g. headers).
+//
+// The blank fields of the metadata are filled by the bus.
+// If triggerContext carries the metadata of an event (the context given to a listener),
+// the correlation ID is inherited from it and the causation ID becomes its ID.
+func TriggerEnvelope[E any](triggerContext gocontext.Context, envelope entity.Envelope[E]) ([]entity.EventContext, error) {
+	return triggerEvent(triggerContext, envelope.Entity, envelope.Metadata, true)
+}
+
+// TriggerAny
+//
+// Triggers an untyped entity. The entity is dispatched to the listeners registered for its dynamic type,
+// as if it had been triggered with TriggerEnvelope.
+func TriggerAny(triggerContext gocontext.Context, elem any, metadata entity.Metadata) ([]entity.EventContext, error) {
+	return triggerEvent(triggerContext, elem, metadata, true)
+}
+
+// TriggerEncoded
+//
+// Decodes the payload into the type registered under the wire name and triggers it like TriggerAny.
+// errors.UnknownEventTypeErr is returned if no type has been registered under the wire name.
+func TriggerEncoded(
+	triggerContext gocontext.Context,
+	registry *codec.Registry,
+	wireName string,
+	payload []byte,
+	metadata entity.Metadata,
+) ([]entity.EventContext, error) {
+	event, err := registry.Decode(wireName, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return TriggerAny(triggerContext, event, metadata)
+}
+
+// triggerEvent
+//
+// Completes the metadata, writes the event ahead (if a write-ahead log is enabled and writeAhead is true)
+// and queues the event sets of every listener registered for the dynamic type of elem.
+// The event sets are queued all or none: no EventContext is returned with an error.
+func triggerEvent(triggerContext gocontext.Context, elem any, metadata entity.Metadata, writeAhead bool) ([]entity.EventContext, error) {
+	ctx := currentApplicationContext()
+	if ctx == nil {
+		return nil, errors.ApplicationNotRunningErr
+	}
+	switch ctx.Status() {
+	case context.NotStarted:
+		return nil, errors.ApplicationNotRunningErr
+	case context.Draining, context.Closed:
+		return nil, errors.ApplicationClosedErr
+	}
+
 	typeVal := reflect.TypeOf(elem)
-	listeners := appContext.GetEventListener(typeVal)
-	if len(listeners) == 0 {
+	registrations := ctx.GetEventListenerRegistrations(typeVal)
+	if len(registrations) == 0 {
 		return nil, errors.NotFoundEventListenerErr
 	}
 
-	var ctxs []entity.EventContext
-	for _, listener := range listeners {
-		specifiedListener, ok := listener.(entity.EventListener[E])
+	var parent *entity.Metadata
+	if parentMetadata, ok := entity.MetadataFromContext(triggerContext); ok {
+		parent = &parentMetadata
+	}
+	metadata = metadata.Complete(parent, time.Now())
+
+	var sets []entity.EventSet
+	for _, registration := range registrations {
+		if registration.NewEventSet == nil {
+			return nil, errors.NotFoundEventListenerErr
+		}
+
+		set, ok := registration.NewEventSet(elem, metadata)
 		if !ok {
 			return nil, errors.NotFoundEventListenerErr
 		}
+		sets = append(sets, set)
+	}
+
+	log := currentWriteAheadLog()
+	if !writeAhead {
+		log = nil
+	}
+	// tracked before the entry is written, so that a concurrent EnableWAL does not replay it
+	if log != nil && !trackWriteAhead(ctx, metadata.ID) {
+		// the entry of the ID is being processed, which completes it
+		log = nil
+	}
+	if log != nil {
+		written, err := log.Append(elem, metadata)
+		if err != nil || !written {
+			untrackWriteAhead(ctx, metadata.ID)
+			log = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		set := entity.NewEventSet(specifiedListener, elem)
+	if err := ctx.QueueListenerEventSets(gocontext.WithoutCancel(triggerContext), registrations, sets); err != nil {
+		if log != nil {
+			// the trigger has failed, so the entry must not be replayed
+			err = stderrors.Join(err, log.Complete(metadata.ID))
+			untrackWriteAhead(ctx, metadata.ID)
+		}
+		return nil, err
+	}
+	ctxs := make([]entity.EventContext, 0, len(sets))
+	for _, set := range sets {
 		ctxs = append(ctxs, set.Context())
-		appContext.QueueEventSet(set)
+	}
+	if slot, ok := triggerContext.Value(replySlotKey{}).(*replySlot); ok {
+		slot.watch(registrations, ctxs)
+	}
+
+	if log != nil {
+		completeWhenSucceeded(ctx, log, metadata.ID, ctxs)
 	}
 
 	return ctxs, nil
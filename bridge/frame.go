@@ -0,0 +1,57 @@
+package bridge
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/aivyss/eventx/entity"
+	"io"
+)
+
+// maxFrameSize bounds the size of a frame read from a connection.
+const maxFrameSize = 64 << 20
+
+// frame
+//
+// An event sent to a Receiver, or the acknowledgement of an event (only Seq is set).
+// Frames are written as [length uint32][json body].
+type frame struct {
+	Seq      uint64           `json:"s"`
+	TypeName string           `json:"t,omitempty"`
+	Payload  []byte           `json:"p,omitempty"`
+	Metadata *entity.Metadata `json:"m,omitempty"`
+}
+
+func writeFrame(w io.Writer, f frame) error {
+	body, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint32(buf, uint32(len(body)))
+	copy(buf[4:], body)
+
+	_, err = w.Write(buf)
+	return err
+}
+
+func readFrame(r *bufio.Reader) (frame, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return frame{}, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return frame{}, io.ErrUnexpectedEOF
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return frame{}, err
+	}
+
+	var f frame
+	err := json.Unmarshal(body, &f)
+	return f, err
+}
@@ -0,0 +1,204 @@
+package bridge
+
+import (
+	"bufio"
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"net"
+	"sync"
+	"time"
+)
+
+type ReceiverOptions struct {
+	// Registry decodes the events. It is required.
+	Registry *codec.Registry
+	// Trigger injects a decoded event into the local bus. It is eventx.TriggerAny when it is nil.
+	Trigger func(ctx gocontext.Context, event any, metadata entity.Metadata) ([]entity.EventContext, error)
+	// OnError receives the connection errors and the events which have been dropped.
+	OnError func(err error)
+	// MinBackoff and MaxBackoff bound the delay before accepting again after an accept error, which doubles after every failure.
+	// The delay is reset once a connection has been accepted.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Receiver
+//
+// Accepts the connections of the Senders and triggers the received events into the local bus.
+//
+// An event is acknowledged once it has been triggered. If the local application is not running, the connection is closed
+// so that the sender sends the event again after reconnecting; the events which cannot be triggered for another reason
+// (e.g. they cannot be decoded or have no listener) are acknowledged and reported to OnError.
+type Receiver struct {
+	listener net.Listener
+	options  ReceiverOptions
+	lock     sync.Mutex
+	conns    map[net.Conn]struct{}
+	closed   bool
+	// stop is closed by Close.
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Listen
+//
+// Listens on the address ("tcp" or "unix" network) and starts receiving events.
+func Listen(network string, address string, options ReceiverOptions) (*Receiver, error) {
+	if options.Registry == nil {
+		return nil, errors.InvalidConfigurationErr
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReceiver(listener, options)
+}
+
+// NewReceiver
+//
+// Starts receiving events from the connections accepted by the listener.
+func NewReceiver(listener net.Listener, options ReceiverOptions) (*Receiver, error) {
+	if listener == nil || options.Registry == nil {
+		return nil, errors.InvalidConfigurationErr
+	}
+	if options.Trigger == nil {
+		options.Trigger = eventx.TriggerAny
+	}
+	if options.OnError == nil {
+		options.OnError = func(error) {}
+	}
+	if options.MinBackoff <= 0 {
+		options.MinBackoff = DefaultMinBackoff
+	}
+	if options.MaxBackoff < options.MinBackoff {
+		options.MaxBackoff = max(DefaultMaxBackoff, options.MinBackoff)
+	}
+
+	r := &Receiver{
+		listener: listener,
+		options:  options,
+		conns:    map[net.Conn]struct{}{},
+		stop:     make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go r.accept()
+
+	return r, nil
+}
+
+func (r *Receiver) Addr() net.Addr {
+	return r.listener.Addr()
+}
+
+// Close
+//
+// Stops accepting connections and closes the open ones.
+func (r *Receiver) Close() error {
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.stop)
+	err := r.listener.Close()
+	for conn := range r.conns {
+		_ = conn.Close()
+	}
+	r.lock.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+func (r *Receiver) accept() {
+	defer r.wg.Done()
+
+	backoff := r.options.MinBackoff
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			r.lock.Lock()
+			closed := r.closed
+			r.lock.Unlock()
+			if closed {
+				return
+			}
+
+			// e.g. the process is out of file descriptors, which a retry at once would not fix
+			r.options.OnError(err)
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, r.options.MaxBackoff)
+			continue
+		}
+		backoff = r.options.MinBackoff
+
+		r.lock.Lock()
+		if r.closed {
+			r.lock.Unlock()
+			_ = conn.Close()
+			return
+		}
+		r.conns[conn] = struct{}{}
+		r.wg.Add(1)
+		r.lock.Unlock()
+
+		go r.serve(conn)
+	}
+}
+
+func (r *Receiver) serve(conn net.Conn) {
+	defer r.wg.Done()
+	defer func() {
+		_ = conn.Close()
+
+		r.lock.Lock()
+		delete(r.conns, conn)
+		r.lock.Unlock()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		f, err := readFrame(reader)
+		if err != nil {
+			return
+		}
+
+		if err := r.receive(f); err != nil {
+			r.options.OnError(err)
+			if stderrors.Is(err, errors.ApplicationNotRunningErr) || stderrors.Is(err, errors.ApplicationClosedErr) {
+				return
+			}
+		}
+
+		if err := writeFrame(conn, frame{Seq: f.Seq}); err != nil {
+			return
+		}
+	}
+}
+
+func (r *Receiver) receive(f frame) error {
+	event, err := r.options.Registry.Decode(f.TypeName, f.Payload)
+	if err != nil {
+		return err
+	}
+
+	var metadata entity.Metadata
+	if f.Metadata != nil {
+		metadata = *f.Metadata
+	}
+
+	_, err = r.options.Trigger(gocontext.Background(), event, metadata)
+	return err
+}
@@ -0,0 +1,303 @@
+package bridge
+
+import (
+	"bufio"
+	gocontext "context"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBufferSize  = 1024
+	DefaultMinBackoff  = 100 * time.Millisecond
+	DefaultMaxBackoff  = 10 * time.Second
+	DefaultDialTimeout = 5 * time.Second
+)
+
+type SenderOptions struct {
+	// Network is "tcp" or "unix". (See net.Dial)
+	Network string
+	Address string
+	// Registry encodes the events. It is required.
+	Registry *codec.Registry
+	// BufferSize is the number of the unacknowledged events kept while disconnected. It is DefaultBufferSize when it is 0.
+	BufferSize int
+	// MinBackoff and MaxBackoff bound the delay between the reconnection attempts, which doubles after every failure.
+	// A connection dropping before MaxBackoff counts as a failure, and the delay is reset once a connection has stayed up for MaxBackoff.
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	DialTimeout time.Duration
+	// OnError receives the connection errors.
+	OnError func(err error)
+}
+
+// Sender
+//
+// Forwards events to a remote Receiver.
+//
+// The events are buffered until the receiver acknowledges them, and the unacknowledged ones are sent again after a reconnection,
+// so the delivery is at-least-once: the receiving side can deduplicate by the event ID (see the inbox package).
+type Sender struct {
+	options SenderOptions
+	lock    sync.Mutex
+	cond    *sync.Cond
+	// pending holds the unacknowledged frames in the order of their sequence numbers.
+	pending []frame
+	// written is the number of the pending frames written to the current connection.
+	written int
+	nextSeq uint64
+	conn    net.Conn
+	broken  bool
+	closed  bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewSender
+//
+// Creates a Sender and starts connecting to the receiver in the background.
+func NewSender(options SenderOptions) (*Sender, error) {
+	if options.Registry == nil || options.Network == "" || options.Address == "" || options.BufferSize < 0 {
+		return nil, errors.InvalidConfigurationErr
+	}
+	if options.BufferSize == 0 {
+		options.BufferSize = DefaultBufferSize
+	}
+	if options.MinBackoff <= 0 {
+		options.MinBackoff = DefaultMinBackoff
+	}
+	if options.MaxBackoff < options.MinBackoff {
+		options.MaxBackoff = max(DefaultMaxBackoff, options.MinBackoff)
+	}
+	if options.DialTimeout <= 0 {
+		options.DialTimeout = DefaultDialTimeout
+	}
+	if options.OnError == nil {
+		options.OnError = func(error) {}
+	}
+
+	s := &Sender{
+		options: options,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.lock)
+
+	go s.run()
+
+	return s, nil
+}
+
+// Forward
+//
+// Registers an event listener forwarding the events of type E of the local bus to the sender.
+// E must be registered to the codec.Registry of the sender.
+func Forward[E any](s *Sender) error {
+	return eventx.RegisterFuncEnvelopeAsEventListener(func(_ gocontext.Context, envelope entity.Envelope[E]) error {
+		return s.Send(envelope.Entity, envelope.Metadata)
+	})
+}
+
+// Send
+//
+// Buffers the event to be sent.
+// errors.BufferFullErr is returned if BufferSize events are waiting for the acknowledgement.
+func (s *Sender) Send(event any, metadata entity.Metadata) error {
+	typeName, payload, err := s.options.Registry.Encode(event)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errors.BridgeClosedErr
+	}
+	if len(s.pending) >= s.options.BufferSize {
+		return errors.BufferFullErr
+	}
+
+	s.nextSeq += 1
+	s.pending = append(s.pending, frame{
+		Seq:      s.nextSeq,
+		TypeName: typeName,
+		Payload:  payload,
+		Metadata: &metadata,
+	})
+	s.cond.Broadcast()
+
+	return nil
+}
+
+// Pending
+//
+// Returns the number of the unacknowledged events.
+func (s *Sender) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.pending)
+}
+
+// Flush
+//
+// Waits until every buffered event has been acknowledged, or ctx is done.
+func (s *Sender) Flush(ctx gocontext.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for s.Pending() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// Close
+//
+// Closes the connection. The unacknowledged events are discarded. (See Flush)
+func (s *Sender) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.stop)
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	s.cond.Broadcast()
+	s.lock.Unlock()
+
+	<-s.done
+	return nil
+}
+
+func (s *Sender) run() {
+	defer close(s.done)
+
+	backoff := s.options.MinBackoff
+	for {
+		conn, err := net.DialTimeout(s.options.Network, s.options.Address, s.options.DialTimeout)
+		if err != nil {
+			s.options.OnError(err)
+		} else {
+			connectedAt := time.Now()
+			if !s.serve(conn) {
+				return
+			}
+			if time.Since(connectedAt) >= s.options.MaxBackoff {
+				// the connection has been stable, so it is reconnected at once
+				backoff = s.options.MinBackoff
+				continue
+			}
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, s.options.MaxBackoff)
+	}
+}
+
+// serve
+//
+// Writes the pending frames to the connection until it breaks. It returns false if the sender has been closed.
+func (s *Sender) serve(conn net.Conn) bool {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		_ = conn.Close()
+		return false
+	}
+	s.conn = conn
+	s.broken = false
+	// the unacknowledged frames are sent again
+	s.written = 0
+	s.lock.Unlock()
+
+	go s.readAcks(conn)
+
+	writer := bufio.NewWriter(conn)
+	for {
+		s.lock.Lock()
+		for !s.closed && !s.broken && s.written == len(s.pending) {
+			s.cond.Wait()
+		}
+		if s.closed || s.broken {
+			closed := s.closed
+			s.lock.Unlock()
+			_ = conn.Close()
+			return !closed
+		}
+		frames := append([]frame(nil), s.pending[s.written:]...)
+		s.written = len(s.pending)
+		s.lock.Unlock()
+
+		for _, f := range frames {
+			if err := writeFrame(writer, f); err != nil {
+				s.disconnect(conn, err)
+				break
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			s.disconnect(conn, err)
+		}
+	}
+}
+
+// readAcks
+//
+// Removes the acknowledged frames from the pending ones.
+func (s *Sender) readAcks(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		ack, err := readFrame(reader)
+		if err != nil {
+			s.disconnect(conn, err)
+			return
+		}
+
+		s.lock.Lock()
+		for len(s.pending) > 0 && s.pending[0].Seq <= ack.Seq {
+			s.pending = s.pending[1:]
+			if s.written > 0 {
+				s.written -= 1
+			}
+		}
+		s.lock.Unlock()
+	}
+}
+
+// disconnect
+//
+// Marks the connection broken, so that serve reconnects.
+func (s *Sender) disconnect(conn net.Conn, err error) {
+	s.lock.Lock()
+	if s.conn != conn || s.broken {
+		s.lock.Unlock()
+		return
+	}
+	s.broken = true
+	closed := s.closed
+	_ = conn.Close()
+	s.cond.Broadcast()
+	s.lock.Unlock()
+
+	if !closed {
+		s.options.OnError(err)
+	}
+}
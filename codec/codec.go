@@ -0,0 +1,74 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec
+//
+// A serialization format of the events.
+// Unmarshal receives a pointer to a value of the registered type.
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSON encodes the events with encoding/json. It is the default Codec of a Registry.
+	JSON Codec = jsonCodec{}
+	// Gob encodes the events with encoding/gob.
+	Gob Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string {
+	return "gob"
+}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// UpcastJSON
+//
+// Returns an Upcaster editing the JSON object of the payload (e.g. renaming or adding fields).
+func UpcastJSON(upcast func(object map[string]any) error) Upcaster {
+	return func(payload []byte) ([]byte, error) {
+		var object map[string]any
+		if err := json.Unmarshal(payload, &object); err != nil {
+			return nil, err
+		}
+		if err := upcast(object); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(object)
+	}
+}
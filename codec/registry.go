@@ -0,0 +1,332 @@
+package codec
+
+import (
+	"fmt"
+	"github.com/aivyss/eventx/errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const DefaultVersion = 1
+
+// TypeInfo
+//
+// The stable name and the version an event type is serialized with.
+type TypeInfo struct {
+	Name    string
+	Version int
+	Type    reflect.Type
+	Codec   Codec
+}
+
+// WireName
+//
+// Returns the name written next to the payload: "<name>/v<version>"
+func (i TypeInfo) WireName() string {
+	return WireName(i.Name, i.Version)
+}
+
+func WireName(name string, version int) string {
+	return fmt.Sprintf("%s/v%d", name, version)
+}
+
+// ParseWireName
+//
+// Splits a wire name into the name and the version. A name without a version is DefaultVersion.
+func ParseWireName(wireName string) (name string, version int) {
+	i := strings.LastIndex(wireName, "/v")
+	if i < 0 {
+		return wireName, DefaultVersion
+	}
+
+	version, err := strconv.Atoi(wireName[i+2:])
+	if err != nil {
+		return wireName, DefaultVersion
+	}
+
+	return wireName[:i], version
+}
+
+// Versioned
+//
+// Implemented by the event types declaring their schema version.
+// It is the version the type is registered under unless WithVersion is given.
+type Versioned interface {
+	SchemaVersion() int
+}
+
+// Upcaster
+//
+// Transforms a payload of a version into the payload of the next version.
+type Upcaster func(payload []byte) ([]byte, error)
+
+type typeKey struct {
+	name    string
+	version int
+}
+
+// Registry
+//
+// Maps the Go event types to stable names and versions and back.
+//
+// The payloads of the older versions are upcasted to the current version (the highest registered one) of their name
+// before they are decoded, through the chain of the registered Upcasters.
+//
+// Registry implements wal.Codec, so it can be given to a write-ahead log as it is.
+type Registry struct {
+	lock         sync.RWMutex
+	defaultCodec Codec
+	byType       map[reflect.Type]TypeInfo
+	byName       map[typeKey]TypeInfo
+	current      map[string]int
+	upcasters    map[typeKey]Upcaster
+}
+
+// NewRegistry
+//
+// Creates a Registry encoding the event types registered without WithCodec with defaultCodec (JSON when it is nil).
+func NewRegistry(defaultCodec Codec) *Registry {
+	if defaultCodec == nil {
+		defaultCodec = JSON
+	}
+
+	return &Registry{
+		defaultCodec: defaultCodec,
+		byType:       map[reflect.Type]TypeInfo{},
+		byName:       map[typeKey]TypeInfo{},
+		current:      map[string]int{},
+		upcasters:    map[typeKey]Upcaster{},
+	}
+}
+
+type Option func(info *TypeInfo)
+
+// WithVersion
+//
+// Registers the type under the given version instead of DefaultVersion.
+func WithVersion(version int) Option {
+	return func(info *TypeInfo) {
+		info.Version = version
+	}
+}
+
+// WithCodec
+//
+// Encodes the type with the given Codec instead of the default one of the Registry.
+func WithCodec(codec Codec) Option {
+	return func(info *TypeInfo) {
+		info.Codec = codec
+	}
+}
+
+// schemaVersion
+//
+// Returns the version declared by Versioned on the type or on its pointer.
+// A pointer type is asked through a new value, since its zero value is a nil receiver.
+func schemaVersion(typeVal reflect.Type) (int, bool) {
+	value := reflect.New(typeVal)
+	if typeVal.Kind() == reflect.Pointer {
+		value.Elem().Set(reflect.New(typeVal.Elem()))
+	}
+
+	if versioned, ok := value.Elem().Interface().(Versioned); ok {
+		return versioned.SchemaVersion(), true
+	}
+	if versioned, ok := value.Interface().(Versioned); ok {
+		return versioned.SchemaVersion(), true
+	}
+
+	return 0, false
+}
+
+// Register
+//
+// Registers E under the stable name, with the version declared by Versioned or DefaultVersion.
+// A type can be registered once, and a name and version pair can be taken by one type.
+func Register[E any](r *Registry, name string, options ...Option) error {
+	info := TypeInfo{
+		Name:    name,
+		Version: DefaultVersion,
+		Type:    reflect.TypeOf((*E)(nil)).Elem(),
+		Codec:   r.defaultCodec,
+	}
+	if version, ok := schemaVersion(info.Type); ok {
+		info.Version = version
+	}
+	for _, option := range options {
+		option(&info)
+	}
+	if info.Name == "" || info.Version < 1 || info.Codec == nil {
+		return errors.InvalidConfigurationErr
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := typeKey{name: info.Name, version: info.Version}
+	if _, ok := r.byType[info.Type]; ok {
+		return errors.AlreadyRegisteredErr
+	}
+	if _, ok := r.byName[key]; ok {
+		return errors.AlreadyRegisteredErr
+	}
+
+	r.byType[info.Type] = info
+	r.byName[key] = info
+	r.current[info.Name] = max(r.current[info.Name], info.Version)
+
+	return nil
+}
+
+// RegisterUpcaster
+//
+// Registers the Upcaster transforming the payloads of the name from fromVersion to fromVersion+1.
+func (r *Registry) RegisterUpcaster(name string, fromVersion int, upcaster Upcaster) error {
+	if name == "" || fromVersion < 1 || upcaster == nil {
+		return errors.InvalidConfigurationErr
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	key := typeKey{name: name, version: fromVersion}
+	if _, ok := r.upcasters[key]; ok {
+		return errors.AlreadyRegisteredErr
+	}
+	r.upcasters[key] = upcaster
+
+	return nil
+}
+
+// CurrentVersion
+//
+// Returns the highest version registered under the name.
+func (r *Registry) CurrentVersion(name string) (int, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	version, ok := r.current[name]
+	return version, ok
+}
+
+// Upcast
+//
+// Transforms the payload towards the current version of its name through the chain of the Upcasters,
+// and returns the wire name of the version it has reached.
+// The payload is returned as it is if the name is unknown or no Upcaster is registered for its version.
+func (r *Registry) Upcast(wireName string, payload []byte) (string, []byte, error) {
+	name, version := ParseWireName(wireName)
+
+	r.lock.RLock()
+	chain := r.upcastChain(name, version)
+	r.lock.RUnlock()
+
+	if len(chain) == 0 {
+		return wireName, payload, nil
+	}
+	for _, upcaster := range chain {
+		var err error
+		if payload, err = upcaster(payload); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return WireName(name, version+len(chain)), payload, nil
+}
+
+// DecodedType
+//
+// Returns the TypeInfo the payloads of the wire name are decoded into, after upcasting.
+func (r *Registry) DecodedType(wireName string) (TypeInfo, bool) {
+	name, version := ParseWireName(wireName)
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	info, ok := r.byName[typeKey{name: name, version: version + len(r.upcastChain(name, version))}]
+	return info, ok
+}
+
+// upcastChain
+//
+// Returns the Upcasters transforming the payloads of the version towards the current version of the name,
+// up to the first missing one. The lock must be held.
+func (r *Registry) upcastChain(name string, version int) []Upcaster {
+	var chain []Upcaster
+	for v := version; v < r.current[name]; v++ {
+		upcaster, ok := r.upcasters[typeKey{name: name, version: v}]
+		if !ok {
+			break
+		}
+		chain = append(chain, upcaster)
+	}
+
+	return chain
+}
+
+// Lookup
+//
+// Returns the TypeInfo of the Go type.
+func (r *Registry) Lookup(typeVal reflect.Type) (TypeInfo, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	info, ok := r.byType[typeVal]
+	return info, ok
+}
+
+// Resolve
+//
+// Returns the TypeInfo registered under the wire name.
+func (r *Registry) Resolve(wireName string) (TypeInfo, bool) {
+	name, version := ParseWireName(wireName)
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	info, ok := r.byName[typeKey{name: name, version: version}]
+	return info, ok
+}
+
+// Encode
+//
+// Serializes the event with the Codec of its type.
+// errors.UnknownEventTypeErr is returned if the type of the event has not been registered.
+func (r *Registry) Encode(event any) (wireName string, payload []byte, err error) {
+	info, ok := r.Lookup(reflect.TypeOf(event))
+	if !ok {
+		return "", nil, errors.UnknownEventTypeErr
+	}
+
+	payload, err = info.Codec.Marshal(event)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return info.WireName(), payload, nil
+}
+
+// Decode
+//
+// Upcasts the payload (see Upcast) and deserializes it into a value of the type registered under the resulting wire name.
+// errors.UnknownEventTypeErr is returned if no type has been registered under it.
+func (r *Registry) Decode(wireName string, payload []byte) (any, error) {
+	wireName, payload, err := r.Upcast(wireName, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	info, ok := r.Resolve(wireName)
+	if !ok {
+		return nil, errors.UnknownEventTypeErr
+	}
+
+	value := reflect.New(info.Type)
+	if err := info.Codec.Unmarshal(payload, value.Interface()); err != nil {
+		return nil, err
+	}
+
+	return value.Elem().Interface(), nil
+}
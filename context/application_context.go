@@ -2,17 +2,25 @@ package context
 
 import (
 	"context"
-	"fmt"
+	stderrors "errors"
 	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
 	"github.com/aivyss/typex"
 	"reflect"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	DefaultEventChannelBufferSize = 5
 	DefaultEventProcessPoolSize   = 10
+	DefaultDispensePoolSize       = 3
 	DefaultMultiEventMode         = true
+	// DefaultCloseTimeout bounds the drain of Close.
+	DefaultCloseTimeout = 30 * time.Second
+	dispenseBufferSize  = 1
 )
 
 // ApplicationContext
@@ -45,6 +53,43 @@ type ApplicationContext struct {
 	eventListenerConfig *EventListenerConfig
 	// EventListenerDispenseChannel is an intermediate layer for event listener processing distribution.
 	eventListenerDispenseChannel *EventListenerDispenseChannel
+	// statusLock
+	//
+	// Guards status transitions so that no event is accepted after draining has started.
+	statusLock sync.RWMutex
+	// status is the current lifecycle state of the context.
+	status ApplicationStatus
+	// closing is closed when the context leaves Running. (See Closing)
+	closing chan struct{}
+	// closed is closed when the context becomes Closed.
+	closed chan struct{}
+	// inFlight
+	//
+	// Counts the accepted event sets that have not finished yet (including their after runners).
+	// Close waits on it while draining.
+	inFlight sync.WaitGroup
+	// listenerLock guards eventListenerConfig.ListenerMap and registrationList.
+	listenerLock sync.RWMutex
+	// registrationList holds every ListenerRegistration in registration order.
+	registrationList []*ListenerRegistration
+	// unregisteredStats collects the statistics of the event sets queued without a ListenerRegistration.
+	unregisteredStats *listenerStats
+	// reconfigureLock serializes Reconfigure calls.
+	reconfigureLock sync.Mutex
+	// dispensePool distributes the event sets of the dispense channel to the event channel.
+	dispensePool *workerPool[*eventTask]
+	// processPool executes the event runners of the event channel.
+	processPool *workerPool[entity.EventRunner]
+	// afterPool executes the after runners (then, catch) of the after channel.
+	afterPool *workerPool[entity.EventAfterRunner]
+	// autoscalerLock guards autoscaler.
+	autoscalerLock sync.Mutex
+	// autoscaler resizes the event process pool in the adaptive mode. It is nil when the adaptive mode is disabled.
+	autoscaler *autoscaler
+	// logSetting holds the Logger of the context. (silent by default)
+	logSetting *logSettingHolder
+	// tracer holds the Tracer of the context. (disabled by default)
+	tracer atomic.Pointer[tracerHolder]
 }
 
 // NewApplicationContext
@@ -56,40 +101,273 @@ type ApplicationContext struct {
 func NewApplicationContext(eventChannelBufferSize int, eventProcessPoolSize int, multiEventMode bool) *ApplicationContext {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &ApplicationContext{
+	appCtx := &ApplicationContext{
 		innerContext:       ctx,
 		innerContextCancel: cancel,
+		closing:            make(chan struct{}),
+		closed:             make(chan struct{}),
 		eventChannel: &EventChannel{
-			Channel:           make(chan entity.EventRunner, eventChannelBufferSize),
-			AfterChannel:      make(chan entity.EventAfterRunner, eventChannelBufferSize),
-			ChannelBufferSize: eventChannelBufferSize,
-			ProcessPoolSize:   eventProcessPoolSize,
+			Channel:      NewEventQueue[entity.EventRunner](eventChannelBufferSize),
+			AfterChannel: NewEventQueue[entity.EventAfterRunner](eventChannelBufferSize),
 		},
 		eventListenerConfig: &EventListenerConfig{
 			MultiEventMode: multiEventMode,
 			ListenerMap:    typex.NewMultiMap[reflect.Type, any](),
 		},
 		eventListenerDispenseChannel: &EventListenerDispenseChannel{
-			DispenseBufferSize: 1,
-			DispensePoolSize:   3,
-			DispenseChannel:    make(chan entity.EventSet, 1),
+			DispenseChannel: NewEventQueue[*eventTask](dispenseBufferSize),
 		},
+		unregisteredStats: newListenerStats(),
+		logSetting:        newLogSettingHolder(),
 	}
+
+	appCtx.dispensePool = newWorkerPool(
+		"event listener dispense pool",
+		DefaultDispensePoolSize,
+		appCtx.eventListenerDispenseChannel.DispenseChannel,
+		appCtx.dispenseEventTask,
+		appCtx.logger,
+	)
+	appCtx.processPool = newWorkerPool(
+		"event process pool",
+		eventProcessPoolSize,
+		appCtx.eventChannel.Channel,
+		appCtx.processEventRunner,
+		appCtx.logger,
+	)
+	appCtx.afterPool = newWorkerPool(
+		"after event process pool",
+		eventProcessPoolSize,
+		appCtx.eventChannel.AfterChannel,
+		appCtx.processEventAfterRunner,
+		appCtx.logger,
+	)
+
+	return appCtx
 }
 
 // QueueEventRunner
 //
 // Takes a function literal(`func() func()`) that contains event execution content and sends it to the event processing channel.
 func (ctx *ApplicationContext) QueueEventRunner(runner entity.EventRunner) {
-	ctx.eventChannel.Channel <- runner
+	ctx.eventChannel.Channel.Send(ctx.innerContext.Done(), runner)
 }
 
 // QueueEventSet
 //
 // Sends an entity.EventSet with the entity publishing events
 // and the entity.EventListener that receives and processes those events to the event distribution channel.
-func (ctx *ApplicationContext) QueueEventSet(set entity.EventSet) {
-	ctx.eventListenerDispenseChannel.DispenseChannel <- set
+//
+// Returns errors.ApplicationNotRunningErr before ConsumeEventRunner is executed,
+// and errors.ApplicationClosedErr once Close has been requested.
+func (ctx *ApplicationContext) QueueEventSet(set entity.EventSet) error {
+	return ctx.QueueListenerEventSet(context.Background(), nil, set)
+}
+
+// QueueListenerEventSet
+//
+// Works like QueueEventSet, and the statistics of the event set are collected under the given registration.
+//
+// triggerContext is passed to the Tracer and, if the event set is an entity.ContextualEventSet, to the listener.
+func (ctx *ApplicationContext) QueueListenerEventSet(
+	triggerContext context.Context,
+	registration *ListenerRegistration,
+	set entity.EventSet,
+) error {
+	if err := ctx.acceptEvents(1); err != nil {
+		return err
+	}
+
+	return ctx.queueTask(triggerContext, registration, set)
+}
+
+// QueueListenerEventSets
+//
+// Works like QueueListenerEventSet for the event sets of an event, with the registrations of the same index:
+// they are either all accepted or all rejected.
+// An accepted event set which cannot be queued (the context has been closed meanwhile) fails with errors.ApplicationClosedErr,
+// which is reported by its EventContext.
+func (ctx *ApplicationContext) QueueListenerEventSets(
+	triggerContext context.Context,
+	registrations []*ListenerRegistration,
+	sets []entity.EventSet,
+) error {
+	if err := ctx.acceptEvents(len(sets)); err != nil {
+		return err
+	}
+
+	for i, set := range sets {
+		_ = ctx.queueTask(triggerContext, registrations[i], set)
+	}
+
+	return nil
+}
+
+// queueTask
+//
+// Sends an accepted event set to the event distribution channel.
+func (ctx *ApplicationContext) queueTask(
+	triggerContext context.Context,
+	registration *ListenerRegistration,
+	set entity.EventSet,
+) error {
+	task := &eventTask{
+		set:            set,
+		registration:   registration,
+		acceptedAt:     time.Now(),
+		triggerContext: triggerContext,
+	}
+	stats := ctx.statsOf(task)
+	stats.counters.triggered.Add(1)
+
+	aware, _ := set.(entity.QueueAwareEventSet)
+	if aware != nil {
+		aware.Queued()
+	}
+	if !ctx.eventListenerDispenseChannel.DispenseChannel.Send(ctx.innerContext.Done(), task) {
+		stats.counters.dropped.Add(1)
+		ctx.logger().Warn("eventx event dropped", append(task.logArgs(), "reason", "closed")...)
+		if aware != nil {
+			aware.Dropped()
+		}
+		set.Context().SetResult(errors.ApplicationClosedErr)
+		ctx.finishTask(task)
+		return errors.ApplicationClosedErr
+	}
+
+	return nil
+}
+
+// acceptEvents
+//
+// Registers n new in-flight events if the context is running.
+func (ctx *ApplicationContext) acceptEvents(n int) error {
+	ctx.statusLock.RLock()
+	defer ctx.statusLock.RUnlock()
+
+	switch ctx.status {
+	case NotStarted:
+		return errors.ApplicationNotRunningErr
+	case Running:
+		ctx.inFlight.Add(n)
+		return nil
+	default:
+		return errors.ApplicationClosedErr
+	}
+}
+
+// trackRunner
+//
+// Wraps the runner of an accepted event set so that its statistics are collected
+// and the in-flight count is released once the runner and its after runner (if any) have finished.
+func (ctx *ApplicationContext) trackRunner(task *eventTask) entity.EventRunner {
+	return func() func() {
+		stats := ctx.statsOf(task)
+		start := time.Now()
+		stats.queueWait.observe(start.Sub(task.acceptedAt))
+
+		runContext := task.triggerContext
+		var span Span
+		if tracer := ctx.currentTracer(); tracer != nil {
+			runContext, span = tracer.StartListenerSpan(task.triggerContext, SpanInfo{
+				EventID:     task.set.Context().EventID(),
+				EventType:   task.eventType(),
+				Listener:    task.listenerID(),
+				Attempt:     1,
+				TriggeredAt: task.acceptedAt,
+			})
+		}
+
+		if deferred, ok := task.set.(entity.DeferredEventSet); ok {
+			// the worker is released while the event waits (e.g. for the rest of its batch)
+			deferred.RunnerDeferred(runContext, func(runner entity.EventRunner) {
+				ctx.resumeRunner(task, func() func() {
+					return ctx.completeRunner(task, span, start, runner())
+				})
+			})
+			return nil
+		}
+
+		return ctx.completeRunner(task, span, start, task.run(runContext))
+	}
+}
+
+// resumeRunner
+//
+// Queues the runner of a resumed entity.DeferredEventSet on the event process pool.
+// It is sent from another goroutine, since the set may be resumed by a worker of the pool
+// which must not wait for the room it would free itself.
+func (ctx *ApplicationContext) resumeRunner(task *eventTask, runner entity.EventRunner) {
+	go func() {
+		if !ctx.eventChannel.Channel.Send(ctx.innerContext.Done(), runner) {
+			ctx.statsOf(task).counters.dropped.Add(1)
+			ctx.logger().Warn("eventx event dropped", append(task.logArgs(), "reason", "closed")...)
+			task.set.Context().SetResult(errors.ApplicationClosedErr)
+			ctx.finishTask(task)
+		}
+	}()
+}
+
+// completeRunner
+//
+// Collects the statistics of a finished listener execution and wraps its after runner
+// so that the in-flight count is released once it has finished.
+func (ctx *ApplicationContext) completeRunner(task *eventTask, span Span, start time.Time, afterRunner func()) func() {
+	stats := ctx.statsOf(task)
+	elapsed := time.Since(start)
+	stats.execution.observe(elapsed)
+
+	err := task.set.Context().Err()
+	if err != nil {
+		stats.counters.failed.Add(1)
+	} else {
+		stats.counters.succeeded.Add(1)
+	}
+	ctx.logExecution(task, err, elapsed)
+	if span != nil {
+		span.End(spanOutcome(err), err)
+	}
+
+	if afterRunner == nil {
+		ctx.finishTask(task)
+		return nil
+	}
+
+	return func() {
+		defer ctx.finishTask(task)
+		afterRunner()
+	}
+}
+
+// finishTask
+//
+// Marks the task as finished and releases its in-flight count.
+func (ctx *ApplicationContext) finishTask(task *eventTask) {
+	task.set.Context().Finish()
+	ctx.inFlight.Done()
+}
+
+func spanOutcome(err error) SpanOutcome {
+	var panicErr *entity.PanicError
+	switch {
+	case stderrors.As(err, &panicErr):
+		return SpanOutcomePanicked
+	case err != nil:
+		return SpanOutcomeFailed
+	default:
+		return SpanOutcomeSucceeded
+	}
+}
+
+// statsOf
+//
+// Returns the statistics the task is collected under.
+func (ctx *ApplicationContext) statsOf(task *eventTask) *listenerStats {
+	if task.registration == nil {
+		return ctx.unregisteredStats
+	}
+
+	return task.registration.stats
 }
 
 // ConsumeEventRunner
@@ -98,73 +376,207 @@ func (ctx *ApplicationContext) QueueEventSet(set entity.EventSet) {
 // Event pools asynchronously process events.
 func (ctx *ApplicationContext) ConsumeEventRunner() {
 	ctx.once.Do(func() {
+		ctx.statusLock.Lock()
+		if ctx.status != NotStarted {
+			ctx.statusLock.Unlock()
+			return
+		}
+		ctx.status = Running
+		ctx.statusLock.Unlock()
+
 		setting := "default"
-		if ctx.eventChannel.ChannelBufferSize != DefaultEventChannelBufferSize ||
-			ctx.eventChannel.ProcessPoolSize != DefaultEventProcessPoolSize ||
+		if ctx.ChannelBufferSize() != DefaultEventChannelBufferSize ||
+			ctx.ProcessPoolSize() != DefaultEventProcessPoolSize ||
 			ctx.eventListenerConfig.MultiEventMode {
 			setting = "customized"
 		}
-		fmt.Println(fmt.Sprintf(
-			"[eventx] eventx event channel is running (setting: %s)",
-			setting,
-		))
-		fmt.Println(fmt.Sprintf(
-			"[eventx] EventChannelSize: %d, EventProcessPoolSize: %d",
-			ctx.eventChannel.ChannelBufferSize,
-			ctx.eventChannel.ProcessPoolSize,
-		))
-
-		for i := 0; i < ctx.eventListenerDispenseChannel.DispensePoolSize; i++ {
-			go func(innerContext context.Context) {
-			selectLoop:
-				for {
-					select {
-					case <-innerContext.Done():
-						break selectLoop
-					case eventSet := <-ctx.eventListenerDispenseChannel.DispenseChannel:
-						manageEventRunnerContext(eventSet, func(set entity.EventSet) {
-							ctx.eventChannel.Channel <- set.Runner
-						})
-					}
-				}
-
-				fmt.Println("[eventx] End of event listener dispense pool...")
-			}(ctx.innerContext)
+		ctx.logger().Info(
+			"eventx event channel is running",
+			"setting", setting,
+			"eventChannelSize", ctx.ChannelBufferSize(),
+			"eventProcessPoolSize", ctx.ProcessPoolSize(),
+			"dispensePoolSize", ctx.DispensePoolSize(),
+		)
+
+		done := ctx.innerContext.Done()
+		ctx.dispensePool.start(done)
+		ctx.processPool.start(done)
+		ctx.afterPool.start(done)
+	})
+}
+
+// dispenseEventTask
+//
+// Sends the runner of a runnable event set to the event channel.
+func (ctx *ApplicationContext) dispenseEventTask(task *eventTask) {
+	dispensed := manageEventRunnerContext(task.set, func(set entity.EventSet) {
+		ctx.eventChannel.Channel.Send(ctx.innerContext.Done(), ctx.trackRunner(task))
+	})
+	if !dispensed {
+		// canceled before execution
+		if aware, ok := task.set.(entity.QueueAwareEventSet); ok {
+			aware.Dropped()
 		}
+		ctx.statsOf(task).counters.canceled.Add(1)
+		ctx.logger().Debug("eventx event canceled", task.logArgs()...)
+		ctx.finishTask(task)
+	}
+}
+
+// processEventRunner
+//
+// Executes an event runner and sends its after runner to the after channel.
+func (ctx *ApplicationContext) processEventRunner(runner entity.EventRunner) {
+	afterRunner := runner()
+	if afterRunner != nil {
+		ctx.eventChannel.AfterChannel.Send(ctx.innerContext.Done(), afterRunner)
+	}
+}
 
-		for i := 0; i < ctx.eventChannel.ProcessPoolSize; i++ {
-			go func(innerContext context.Context) {
-			selectLoop:
-				for {
-					select {
-					case <-innerContext.Done():
-						break selectLoop
-					case runner := <-ctx.eventChannel.Channel:
-						afterRunner := runner()
-						if afterRunner != nil {
-							ctx.eventChannel.AfterChannel <- afterRunner
-						}
-					}
-				}
-
-				fmt.Println("[eventx] End of event process pool...")
-			}(ctx.innerContext)
-
-			go func(innerContext context.Context) {
-			selectLoop:
-				for {
-					select {
-					case <-innerContext.Done():
-						break selectLoop
-					case runner := <-ctx.eventChannel.AfterChannel:
-						runner()
-					}
-				}
-
-				fmt.Println("[eventx] End of after event process pool...")
-			}(ctx.innerContext)
+// processEventAfterRunner
+//
+// Executes an after runner (then, catch). A panic is logged instead of ending the worker.
+func (ctx *ApplicationContext) processEventAfterRunner(runner entity.EventAfterRunner) {
+	defer func() {
+		if r := recover(); r != nil {
+			ctx.logger().Error("eventx after event listener panicked", "panic", r, "stack", string(debug.Stack()))
 		}
-	})
+	}()
+
+	runner()
+}
+
+// logExecution
+//
+// Logs the failure, the panic or the slowness of a listener execution.
+func (ctx *ApplicationContext) logExecution(task *eventTask, err error, elapsed time.Duration) {
+	logger := ctx.logger()
+
+	var panicErr *entity.PanicError
+	switch {
+	case stderrors.As(err, &panicErr):
+		logger.Error("eventx event listener panicked", append(task.logArgs(), "panic", panicErr.Value, "stack", string(panicErr.Stack))...)
+	case err != nil:
+		logger.Error("eventx event listener failed", append(task.logArgs(), "error", err)...)
+	}
+
+	if threshold := ctx.logSetting.slowListenerThreshold(); threshold > 0 && elapsed > threshold {
+		logger.Warn("eventx slow event listener", append(task.logArgs(), "elapsed", elapsed, "threshold", threshold)...)
+	}
+}
+
+// Reconfigure
+//
+// Changes the buffer size of the event channels and the sizes of the event pools while the context is running.
+//
+// The registered event listeners and the queued events are kept.
+// Workers are added immediately, and surplus workers end after finishing the event they are processing.
+func (ctx *ApplicationContext) Reconfigure(eventChannelBufferSize int, eventProcessPoolSize int, dispensePoolSize int) error {
+	if eventChannelBufferSize < 0 || eventProcessPoolSize < 1 || dispensePoolSize < 1 {
+		return errors.InvalidConfigurationErr
+	}
+
+	ctx.reconfigureLock.Lock()
+	defer ctx.reconfigureLock.Unlock()
+
+	switch ctx.Status() {
+	case NotStarted:
+		return errors.ApplicationNotRunningErr
+	case Draining, Closed:
+		return errors.ApplicationClosedErr
+	}
+
+	done := ctx.innerContext.Done()
+	ctx.logger().Info(
+		"eventx reconfigured",
+		"eventChannelSize", eventChannelBufferSize,
+		"eventProcessPoolSize", eventProcessPoolSize,
+		"dispensePoolSize", dispensePoolSize,
+	)
+	ctx.eventChannel.Channel.Resize(done, eventChannelBufferSize)
+	ctx.eventChannel.AfterChannel.Resize(done, eventChannelBufferSize)
+	ctx.dispensePool.resize(done, dispensePoolSize)
+	ctx.processPool.resize(done, eventProcessPoolSize)
+	ctx.afterPool.resize(done, eventProcessPoolSize)
+
+	return nil
+}
+
+// EnableAutoscale
+//
+// Turns on the adaptive mode of the event process pool, replacing the previous AutoscaleConfig if any.
+// The pool is resized into the [MinProcessPoolSize, MaxProcessPoolSize] range immediately.
+func (ctx *ApplicationContext) EnableAutoscale(config AutoscaleConfig) error {
+	scaler, err := newAutoscaler(config)
+	if err != nil {
+		return err
+	}
+
+	switch ctx.Status() {
+	case NotStarted:
+		return errors.ApplicationNotRunningErr
+	case Draining, Closed:
+		return errors.ApplicationClosedErr
+	}
+
+	ctx.autoscalerLock.Lock()
+	defer ctx.autoscalerLock.Unlock()
+
+	if ctx.autoscaler != nil {
+		close(ctx.autoscaler.stop)
+	}
+	ctx.autoscaler = scaler
+	go scaler.run(ctx.innerContext.Done(), ctx)
+
+	return nil
+}
+
+// DisableAutoscale
+//
+// Turns off the adaptive mode. The event process pool keeps its current size.
+func (ctx *ApplicationContext) DisableAutoscale() {
+	ctx.autoscalerLock.Lock()
+	defer ctx.autoscalerLock.Unlock()
+
+	if ctx.autoscaler != nil {
+		close(ctx.autoscaler.stop)
+		ctx.autoscaler = nil
+	}
+}
+
+// AutoscaleStats
+//
+// Returns a snapshot of the adaptive mode.
+func (ctx *ApplicationContext) AutoscaleStats() AutoscaleStats {
+	ctx.autoscalerLock.Lock()
+	defer ctx.autoscalerLock.Unlock()
+
+	if ctx.autoscaler == nil {
+		return AutoscaleStats{}
+	}
+
+	return ctx.autoscaler.stats()
+}
+
+// ChannelBufferSize
+//
+// Returns the buffer size of the event channels.
+func (ctx *ApplicationContext) ChannelBufferSize() int {
+	return ctx.eventChannel.Channel.Cap()
+}
+
+// ProcessPoolSize
+//
+// Returns the number of workers of the event process pool.
+func (ctx *ApplicationContext) ProcessPoolSize() int {
+	return ctx.processPool.Size()
+}
+
+// DispensePoolSize
+//
+// Returns the number of workers of the event listener dispense pool.
+func (ctx *ApplicationContext) DispensePoolSize() int {
+	return ctx.dispensePool.Size()
 }
 
 // GetEventListener
@@ -172,24 +584,184 @@ func (ctx *ApplicationContext) ConsumeEventRunner() {
 // Returns the event listeners corresponding to the entity publishing the events.
 // Since it can only return []any, type checking is required on the caller's side.
 func (ctx *ApplicationContext) GetEventListener(typeVal reflect.Type) []any {
-	listeners := ctx.eventListenerConfig.ListenerMap.Get(typeVal)
+	var listeners []any
+	for _, registration := range ctx.GetEventListenerRegistrations(typeVal) {
+		listeners = append(listeners, registration.Listener)
+	}
+
 	return listeners
 }
 
+// GetEventListenerRegistrations
+//
+// Returns the registrations of the event listeners corresponding to the entity publishing the events.
+func (ctx *ApplicationContext) GetEventListenerRegistrations(typeVal reflect.Type) []*ListenerRegistration {
+	ctx.listenerLock.RLock()
+	defer ctx.listenerLock.RUnlock()
+
+	var registrations []*ListenerRegistration
+	for _, registration := range ctx.eventListenerConfig.ListenerMap.Get(typeVal) {
+		registrations = append(registrations, registration.(*ListenerRegistration))
+	}
+
+	return registrations
+}
+
 // RegisterEventListener
 //
 // Registers an event listener in the context.
 func (ctx *ApplicationContext) RegisterEventListener(typeVal reflect.Type, eventListener any) {
-	ctx.eventListenerConfig.ListenerMap.Put(typeVal, eventListener)
+	ctx.listenerLock.Lock()
+	defer ctx.listenerLock.Unlock()
+
+	ctx.register(typeVal, eventListener, nil)
+}
+
+// RegisterEventListenerIfAbsent
+//
+// Registers an event listener and the factory of its event sets in the context.
+// If the context is not in multi event mode and a listener is already registered for the type,
+// errors.AlreadyRegisteredErr is returned.
+func (ctx *ApplicationContext) RegisterEventListenerIfAbsent(
+	typeVal reflect.Type,
+	eventListener any,
+	factory entity.EventSetFactory,
+) (*ListenerRegistration, error) {
+	ctx.listenerLock.Lock()
+	defer ctx.listenerLock.Unlock()
+
+	if !ctx.eventListenerConfig.MultiEventMode && len(ctx.eventListenerConfig.ListenerMap.Get(typeVal)) > 0 {
+		return nil, errors.AlreadyRegisteredErr
+	}
+
+	return ctx.register(typeVal, eventListener, factory), nil
+}
+
+func (ctx *ApplicationContext) register(typeVal reflect.Type, eventListener any, factory entity.EventSetFactory) *ListenerRegistration {
+	order := len(ctx.eventListenerConfig.ListenerMap.Get(typeVal))
+	registration := newListenerRegistration(typeVal, order, eventListener, factory)
+
+	ctx.eventListenerConfig.ListenerMap.Put(typeVal, registration)
+	ctx.registrationList = append(ctx.registrationList, registration)
+
+	return registration
+}
+
+// registrations
+//
+// Returns every ListenerRegistration in registration order.
+func (ctx *ApplicationContext) registrations() []*ListenerRegistration {
+	ctx.listenerLock.RLock()
+	defer ctx.listenerLock.RUnlock()
+
+	return append([]*ListenerRegistration(nil), ctx.registrationList...)
 }
 
 // Close
 //
 // Terminates the context, causing the event pool to end.
 //
+// The context first moves to Draining: new events are rejected and the events already queued are processed.
+// Once they have finished, the event pools end and the context becomes Closed.
+// The drain is bounded by DefaultCloseTimeout. (See CloseContext)
+//
 // Users are encouraged to execute this method with `defer eventx.Close` to ensure that `eventx` safely ends before their application terminates.
 func (ctx *ApplicationContext) Close() {
+	closeContext, cancel := context.WithTimeout(context.Background(), DefaultCloseTimeout)
+	defer cancel()
+
+	_ = ctx.CloseContext(closeContext)
+}
+
+// CloseContext
+//
+// Works like Close, and the drain is bounded by closeContext instead:
+// once it is done, the event pools end without waiting for the remaining events and its error is returned.
+// The remaining events are abandoned, so their EventContexts do not finish.
+//
+// The bound also releases a close requested by an event listener, whose own event would never finish while it waits.
+// A call made while another one is draining waits for that drain to end, bounded by its own closeContext.
+func (ctx *ApplicationContext) CloseContext(closeContext context.Context) error {
+	ctx.statusLock.Lock()
+	switch ctx.status {
+	case Running:
+		ctx.status = Draining
+		ctx.closeClosing()
+		ctx.statusLock.Unlock()
+		ctx.logger().Info("eventx is draining")
+	case NotStarted:
+		ctx.status = Closed
+		ctx.closeClosing()
+		ctx.closeClosed()
+		ctx.statusLock.Unlock()
+		ctx.innerContextCancel()
+		return nil
+	case Draining:
+		// closed by another call, whose drain is waited for
+		ctx.statusLock.Unlock()
+		select {
+		case <-ctx.closed:
+			return nil
+		case <-closeContext.Done():
+			return closeContext.Err()
+		}
+	default:
+		ctx.statusLock.Unlock()
+		return nil
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		ctx.inFlight.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-closeContext.Done():
+		err = closeContext.Err()
+		ctx.logger().Warn("eventx drain interrupted", "error", err)
+	}
 	ctx.innerContextCancel()
+
+	ctx.statusLock.Lock()
+	ctx.status = Closed
+	ctx.closeClosed()
+	ctx.statusLock.Unlock()
+	ctx.logger().Info("eventx is closed")
+
+	return err
+}
+
+// Closing
+//
+// Returns a channel which is closed once Close has been requested, when the context starts draining.
+// Long-running event listeners (e.g. retrying ones) can watch it so that they do not hold the drain.
+func (ctx *ApplicationContext) Closing() <-chan struct{} {
+	return ctx.closing
+}
+
+func (ctx *ApplicationContext) closeClosing() {
+	if ctx.closing != nil {
+		close(ctx.closing)
+	}
+}
+
+func (ctx *ApplicationContext) closeClosed() {
+	if ctx.closed != nil {
+		close(ctx.closed)
+	}
+}
+
+// Status
+//
+// Returns the current lifecycle state of the context.
+func (ctx *ApplicationContext) Status() ApplicationStatus {
+	ctx.statusLock.RLock()
+	defer ctx.statusLock.RUnlock()
+
+	return ctx.status
 }
 
 // IsMultiMode
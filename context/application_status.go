@@ -0,0 +1,42 @@
+package context
+
+// ApplicationStatus
+//
+// The lifecycle state of an ApplicationContext.
+//
+// NotStarted -> Running -> Draining -> Closed
+type ApplicationStatus int32
+
+const (
+	// NotStarted
+	//
+	// The context has been created but its event pools are not running yet.
+	NotStarted ApplicationStatus = iota
+	// Running
+	//
+	// The event pools are running and new events are accepted.
+	Running
+	// Draining
+	//
+	// Close has been requested. New events are rejected while the queued events are still being processed.
+	Draining
+	// Closed
+	//
+	// The event pools have ended.
+	Closed
+)
+
+func (s ApplicationStatus) String() string {
+	switch s {
+	case NotStarted:
+		return "NotStarted"
+	case Running:
+		return "Running"
+	case Draining:
+		return "Draining"
+	case Closed:
+		return "Closed"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,243 @@
+package context
+
+import (
+	"github.com/aivyss/eventx/errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAutoscaleInterval        = 100 * time.Millisecond
+	DefaultAutoscaleIdleTimeout     = 10 * time.Second
+	DefaultAutoscaleTargetQueueWait = 50 * time.Millisecond
+	DefaultAutoscaleScaleStep       = 1
+)
+
+// AutoscaleConfig
+//
+// Settings of the adaptive mode of the event process pool.
+//
+// The pool grows when the queue depth of the event channel exceeds TargetQueueDepth
+// or when the queue wait time (the average wait of the received events, or the wait of the oldest queued one
+// if it is longer) exceeds TargetQueueWait,
+// and it shrinks when some of its workers have been idle for IdleTimeout.
+// The number of workers always stays between MinProcessPoolSize and MaxProcessPoolSize.
+type AutoscaleConfig struct {
+	MinProcessPoolSize int
+	MaxProcessPoolSize int
+	// TargetQueueDepth is ignored when it is 0.
+	TargetQueueDepth int
+	// TargetQueueWait is DefaultAutoscaleTargetQueueWait when both TargetQueueDepth and TargetQueueWait are 0.
+	TargetQueueWait time.Duration
+	// IdleTimeout is DefaultAutoscaleIdleTimeout when it is 0.
+	IdleTimeout time.Duration
+	// Interval is the period of the scaling evaluation. It is DefaultAutoscaleInterval when it is 0.
+	Interval time.Duration
+	// ScaleStep is the number of workers added or removed at once. It is DefaultAutoscaleScaleStep when it is 0.
+	ScaleStep int
+	// OnScale is called (if not nil) after every scaling decision is applied.
+	OnScale func(decision ScalingDecision)
+}
+
+type ScalingReason string
+
+const (
+	ScalingReasonQueueDepth ScalingReason = "QueueDepth"
+	ScalingReasonQueueWait  ScalingReason = "QueueWait"
+	ScalingReasonIdle       ScalingReason = "Idle"
+	ScalingReasonBounds     ScalingReason = "Bounds"
+)
+
+// ScalingDecision
+//
+// A change of the event process pool size made by the adaptive mode.
+type ScalingDecision struct {
+	From       int
+	To         int
+	Reason     ScalingReason
+	QueueDepth int
+	QueueWait  time.Duration
+	At         time.Time
+}
+
+// AutoscaleStats
+//
+// A snapshot of the adaptive mode.
+type AutoscaleStats struct {
+	Enabled            bool
+	MinProcessPoolSize int
+	MaxProcessPoolSize int
+	ScaleUps           int
+	ScaleDowns         int
+	// LastDecision is nil if no decision has been made yet.
+	LastDecision *ScalingDecision
+}
+
+// autoscaler
+//
+// Evaluates the event process pool periodically and resizes it according to AutoscaleConfig.
+type autoscaler struct {
+	config AutoscaleConfig
+	stop   chan struct{}
+	// lock guards the fields below.
+	lock         sync.Mutex
+	scaleUps     int
+	scaleDowns   int
+	lastDecision *ScalingDecision
+}
+
+func newAutoscaler(config AutoscaleConfig) (*autoscaler, error) {
+	if config.MinProcessPoolSize < 1 ||
+		config.MaxProcessPoolSize < config.MinProcessPoolSize ||
+		config.TargetQueueDepth < 0 ||
+		config.TargetQueueWait < 0 ||
+		config.IdleTimeout < 0 ||
+		config.Interval < 0 ||
+		config.ScaleStep < 0 {
+		return nil, errors.InvalidConfigurationErr
+	}
+
+	if config.TargetQueueDepth == 0 && config.TargetQueueWait == 0 {
+		config.TargetQueueWait = DefaultAutoscaleTargetQueueWait
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = DefaultAutoscaleIdleTimeout
+	}
+	if config.Interval == 0 {
+		config.Interval = DefaultAutoscaleInterval
+	}
+	if config.ScaleStep == 0 {
+		config.ScaleStep = DefaultAutoscaleScaleStep
+	}
+
+	return &autoscaler{
+		config: config,
+		stop:   make(chan struct{}),
+	}, nil
+}
+
+// run
+//
+// Evaluates the pool every interval until done or stop is closed.
+func (a *autoscaler) run(done <-chan struct{}, ctx *ApplicationContext) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	idleSince := time.Now()
+	a.apply(done, ctx, ScalingReasonBounds, 0, 0)
+
+selectLoop:
+	for {
+		select {
+		case <-done:
+			break selectLoop
+		case <-a.stop:
+			break selectLoop
+		case now := <-ticker.C:
+			depth := ctx.eventChannel.Channel.Len()
+			wait := ctx.eventChannel.Channel.takeAverageWait()
+			if oldest := ctx.eventChannel.Channel.oldestWait(); oldest > wait {
+				wait = oldest
+			}
+			size := ctx.processPool.Size()
+
+			switch {
+			case a.config.TargetQueueDepth > 0 && depth > a.config.TargetQueueDepth:
+				a.apply(done, ctx, ScalingReasonQueueDepth, depth, wait)
+				idleSince = now
+			case a.config.TargetQueueWait > 0 && wait > a.config.TargetQueueWait:
+				a.apply(done, ctx, ScalingReasonQueueWait, depth, wait)
+				idleSince = now
+			case depth > 0 || int(ctx.processPool.busy.Load()) >= size:
+				// every worker is in use
+				idleSince = now
+			case now.Sub(idleSince) >= a.config.IdleTimeout:
+				a.apply(done, ctx, ScalingReasonIdle, depth, wait)
+				idleSince = now
+			}
+		}
+	}
+}
+
+// apply
+//
+// Resizes the event process pool for the given reason and records the decision if the size has changed.
+func (a *autoscaler) apply(done <-chan struct{}, ctx *ApplicationContext, reason ScalingReason, depth int, wait time.Duration) {
+	ctx.reconfigureLock.Lock()
+
+	from := ctx.processPool.Size()
+	to := from
+	switch reason {
+	case ScalingReasonQueueDepth, ScalingReasonQueueWait:
+		to = from + a.config.ScaleStep
+	case ScalingReasonIdle:
+		to = from - a.config.ScaleStep
+	}
+	if to < a.config.MinProcessPoolSize {
+		to = a.config.MinProcessPoolSize
+	}
+	if to > a.config.MaxProcessPoolSize {
+		to = a.config.MaxProcessPoolSize
+	}
+	if to == from {
+		ctx.reconfigureLock.Unlock()
+		return
+	}
+
+	ctx.processPool.resize(done, to)
+	ctx.afterPool.resize(done, to)
+	ctx.reconfigureLock.Unlock()
+
+	decision := ScalingDecision{
+		From:       from,
+		To:         to,
+		Reason:     reason,
+		QueueDepth: depth,
+		QueueWait:  wait,
+		At:         time.Now(),
+	}
+
+	a.lock.Lock()
+	if to > from {
+		a.scaleUps += 1
+	} else {
+		a.scaleDowns += 1
+	}
+	a.lastDecision = &decision
+	a.lock.Unlock()
+
+	ctx.logger().Info(
+		"eventx event process pool scaled",
+		"from", from,
+		"to", to,
+		"reason", string(reason),
+		"queueDepth", depth,
+		"queueWait", wait,
+	)
+
+	if a.config.OnScale != nil {
+		a.config.OnScale(decision)
+	}
+}
+
+// stats
+//
+// Returns a snapshot of the autoscaler.
+func (a *autoscaler) stats() AutoscaleStats {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	stats := AutoscaleStats{
+		Enabled:            true,
+		MinProcessPoolSize: a.config.MinProcessPoolSize,
+		MaxProcessPoolSize: a.config.MaxProcessPoolSize,
+		ScaleUps:           a.scaleUps,
+		ScaleDowns:         a.scaleDowns,
+	}
+	if a.lastDecision != nil {
+		decision := *a.lastDecision
+		stats.LastDecision = &decision
+	}
+
+	return stats
+}
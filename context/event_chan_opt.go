@@ -3,8 +3,6 @@ package context
 import "github.com/aivyss/eventx/entity"
 
 type EventChannel struct {
-	ChannelBufferSize int
-	ProcessPoolSize   int
-	Channel           chan entity.EventRunner
-	AfterChannel      chan entity.EventAfterRunner
+	Channel      *EventQueue[entity.EventRunner]
+	AfterChannel *EventQueue[entity.EventAfterRunner]
 }
@@ -7,5 +7,6 @@ import (
 
 type EventListenerConfig struct {
 	MultiEventMode bool
-	ListenerMap    typex.MultiMap[reflect.Type, any]
+	// ListenerMap holds *ListenerRegistration values.
+	ListenerMap typex.MultiMap[reflect.Type, any]
 }
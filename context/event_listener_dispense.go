@@ -1,9 +1,5 @@
 package context
 
-import "github.com/aivyss/eventx/entity"
-
 type EventListenerDispenseChannel struct {
-	DispensePoolSize   int
-	DispenseBufferSize int
-	DispenseChannel    chan entity.EventSet
+	DispenseChannel *EventQueue[*eventTask]
 }
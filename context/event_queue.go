@@ -0,0 +1,199 @@
+package context
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventQueue
+//
+// A buffered channel whose buffer size can be changed while it is in use.
+//
+// Senders use Send, and the worker pools receive from the channel returned by receiver.
+// When the queue is resized, the queued values are received from the previous channel first,
+// then the receivers are notified and the senders resume on the new channel, so the values are received in the order they have been sent.
+type EventQueue[T any] struct {
+	// sendLock
+	//
+	// Senders hold the read lock while sending so that no value is sent to a replaced channel.
+	sendLock sync.RWMutex
+	state    atomic.Pointer[eventQueueState[T]]
+	// waitSum and waitCount accumulate the time values spent in the queue until takeAverageWait is called.
+	waitSum   atomic.Int64
+	waitCount atomic.Int64
+	// headSince is a lower bound of the time the oldest queued value has been sent (See oldestWait)
+	headSince atomic.Int64
+	// draining is the signal of a pending resize, raised once the channel is empty. (See Resize)
+	draining atomic.Pointer[drainSignal]
+}
+
+type drainSignal struct {
+	once    sync.Once
+	drained chan struct{}
+}
+
+func (s *drainSignal) raise() {
+	s.once.Do(func() {
+		close(s.drained)
+	})
+}
+
+type eventQueueState[T any] struct {
+	channel chan queuedValue[T]
+	// resized is closed when channel is replaced.
+	resized chan struct{}
+}
+
+type queuedValue[T any] struct {
+	value    T
+	queuedAt time.Time
+}
+
+// NewEventQueue
+//
+// Creates an EventQueue with the given buffer size.
+func NewEventQueue[T any](bufferSize int) *EventQueue[T] {
+	q := &EventQueue[T]{}
+	q.state.Store(&eventQueueState[T]{
+		channel: make(chan queuedValue[T], bufferSize),
+		resized: make(chan struct{}),
+	})
+
+	return q
+}
+
+// Send
+//
+// Blocks until the value is queued or done is closed.
+// Returns whether the value has been queued.
+func (q *EventQueue[T]) Send(done <-chan struct{}, value T) bool {
+	queuedAt := time.Now()
+	if q.Len() == 0 {
+		// the value is the oldest one unless another one is sent first
+		q.advanceHead(queuedAt)
+	}
+
+	return q.send(done, queuedValue[T]{value: value, queuedAt: queuedAt})
+}
+
+func (q *EventQueue[T]) send(done <-chan struct{}, value queuedValue[T]) bool {
+	q.sendLock.RLock()
+	defer q.sendLock.RUnlock()
+
+	select {
+	case q.state.Load().channel <- value:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// Len
+//
+// Returns the number of queued values.
+func (q *EventQueue[T]) Len() int {
+	return len(q.state.Load().channel)
+}
+
+// Cap
+//
+// Returns the buffer size.
+func (q *EventQueue[T]) Cap() int {
+	return cap(q.state.Load().channel)
+}
+
+// Resize
+//
+// Replaces the channel with a new one of the given buffer size.
+//
+// It returns at once, and the replacement takes effect in the background once the values left in the channel have been received:
+// the senders wait until then, so no new value overtakes the queued ones.
+// If done is closed first, the values left are abandoned. A following Resize waits for the pending one.
+func (q *EventQueue[T]) Resize(done <-chan struct{}, bufferSize int) {
+	q.sendLock.Lock()
+	prev := q.state.Load()
+	if cap(prev.channel) == bufferSize {
+		q.sendLock.Unlock()
+		return
+	}
+
+	signal := &drainSignal{drained: make(chan struct{})}
+	q.draining.Store(signal)
+	if len(prev.channel) == 0 {
+		// nothing left, or the receivers have emptied the channel before the signal was stored
+		signal.raise()
+	}
+
+	go func() {
+		defer q.sendLock.Unlock()
+		select {
+		case <-signal.drained:
+		case <-done:
+		}
+
+		q.draining.Store(nil)
+		q.state.Store(&eventQueueState[T]{
+			channel: make(chan queuedValue[T], bufferSize),
+			resized: make(chan struct{}),
+		})
+		close(prev.resized)
+	}()
+}
+
+// receiver
+//
+// Returns the channel to receive from and a channel that is closed when it is replaced.
+func (q *EventQueue[T]) receiver() (<-chan queuedValue[T], <-chan struct{}) {
+	state := q.state.Load()
+	return state.channel, state.resized
+}
+
+// observeReceive
+//
+// Records the time a received value has spent in the queue.
+// The values still queued have been sent after it.
+func (q *EventQueue[T]) observeReceive(queuedAt time.Time) {
+	q.waitSum.Add(int64(time.Since(queuedAt)))
+	q.waitCount.Add(1)
+	q.advanceHead(queuedAt)
+
+	// nothing is sent while a resize is pending, so an empty channel stays empty
+	if signal := q.draining.Load(); signal != nil && q.Len() == 0 {
+		signal.raise()
+	}
+}
+
+func (q *EventQueue[T]) advanceHead(at time.Time) {
+	for {
+		head := q.headSince.Load()
+		if head >= at.UnixNano() || q.headSince.CompareAndSwap(head, at.UnixNano()) {
+			return
+		}
+	}
+}
+
+// oldestWait
+//
+// Returns the time the oldest queued value has spent in the queue at most, or 0 if the queue is empty.
+// Unlike takeAverageWait, it grows while no value is received. (e.g. every worker is stuck)
+func (q *EventQueue[T]) oldestWait() time.Duration {
+	if q.Len() == 0 {
+		return 0
+	}
+
+	return time.Since(time.Unix(0, q.headSince.Load()))
+}
+
+// takeAverageWait
+//
+// Returns the average time the values received since the previous call have spent in the queue.
+func (q *EventQueue[T]) takeAverageWait() time.Duration {
+	count := q.waitCount.Swap(0)
+	sum := q.waitSum.Swap(0)
+	if count == 0 {
+		return 0
+	}
+
+	return time.Duration(sum / count)
+}
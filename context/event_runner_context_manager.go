@@ -2,7 +2,11 @@ package context
 
 import "github.com/aivyss/eventx/entity"
 
-func manageEventRunnerContext(set entity.EventSet, literal func(set entity.EventSet)) {
+// manageEventRunnerContext
+//
+// Executes the literal if the event set is still runnable and marks it as done.
+// Returns whether the literal has been executed.
+func manageEventRunnerContext(set entity.EventSet, literal func(set entity.EventSet)) bool {
 	ctx := set.Context()
 	ctx.Lock()
 	defer ctx.Unlock()
@@ -10,5 +14,9 @@ func manageEventRunnerContext(set entity.EventSet, literal func(set entity.Event
 	if ctx.IsRunnableInternal() {
 		literal(set)
 		ctx.Done = true
+
+		return true
 	}
+
+	return false
 }
@@ -0,0 +1,61 @@
+package context
+
+import (
+	"context"
+	"github.com/aivyss/eventx/entity"
+	"time"
+)
+
+// eventTask
+//
+// An entity.EventSet accepted by the ApplicationContext.
+type eventTask struct {
+	set entity.EventSet
+	// registration is nil when the event set has been queued without a registered listener (QueueEventSet).
+	registration *ListenerRegistration
+	acceptedAt   time.Time
+	// triggerContext is the context.Context the event has been triggered with.
+	triggerContext context.Context
+}
+
+// run
+//
+// Executes the event set with the given context.Context if it accepts one.
+func (t *eventTask) run(runContext context.Context) func() {
+	if set, ok := t.set.(entity.ContextualEventSet); ok {
+		return set.RunnerContext(runContext)
+	}
+
+	return t.set.Runner()
+}
+
+func (t *eventTask) eventType() string {
+	if t.registration == nil {
+		return ""
+	}
+
+	return typeName(t.registration.EventType)
+}
+
+func (t *eventTask) listenerID() string {
+	if t.registration == nil {
+		return ""
+	}
+
+	return t.registration.ID
+}
+
+// logArgs
+//
+// Returns the key/value pairs identifying the task in the log records.
+func (t *eventTask) logArgs() []any {
+	if t.registration == nil {
+		return []any{"eventId", t.set.Context().EventID()}
+	}
+
+	return []any{
+		"listener", t.registration.ID,
+		"eventType", typeName(t.registration.EventType),
+		"eventId", t.set.Context().EventID(),
+	}
+}
@@ -0,0 +1,39 @@
+package context
+
+import (
+	"fmt"
+	"github.com/aivyss/eventx/entity"
+	"reflect"
+)
+
+// ListenerRegistration
+//
+// An event listener registered in the ApplicationContext together with its identity and statistics.
+type ListenerRegistration struct {
+	// ID identifies the listener in statistics. ("<event type>#<registration order>")
+	ID        string
+	EventType reflect.Type
+	Listener  any
+	// NewEventSet creates the event sets of the listener from untyped entities.
+	// It is nil for the listeners registered with ApplicationContext.RegisterEventListener.
+	NewEventSet entity.EventSetFactory
+	stats       *listenerStats
+}
+
+func newListenerRegistration(typeVal reflect.Type, order int, eventListener any, factory entity.EventSetFactory) *ListenerRegistration {
+	return &ListenerRegistration{
+		ID:          fmt.Sprintf("%s#%d", typeName(typeVal), order),
+		EventType:   typeVal,
+		Listener:    eventListener,
+		NewEventSet: factory,
+		stats:       newListenerStats(),
+	}
+}
+
+func typeName(typeVal reflect.Type) string {
+	if typeVal == nil {
+		return "<nil>"
+	}
+
+	return typeVal.String()
+}
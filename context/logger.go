@@ -0,0 +1,94 @@
+package context
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Logger
+//
+// A leveled, structured logger. args are alternating keys and values.
+//
+// *slog.Logger satisfies this interface, so it can be passed as it is.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// NopLogger
+//
+// A Logger that discards every record. It is the default Logger of `eventx`.
+type NopLogger struct{}
+
+func (NopLogger) Debug(string, ...any) {}
+func (NopLogger) Info(string, ...any)  {}
+func (NopLogger) Warn(string, ...any)  {}
+func (NopLogger) Error(string, ...any) {}
+
+// logSetting
+//
+// The logging configuration of an ApplicationContext.
+type logSetting struct {
+	logger Logger
+	// slowListenerThreshold
+	//
+	// A listener execution taking longer than it is logged as a slow handler.
+	// Slow handlers are not logged when it is 0.
+	slowListenerThreshold time.Duration
+}
+
+type logSettingHolder struct {
+	setting atomic.Pointer[logSetting]
+}
+
+func newLogSettingHolder() *logSettingHolder {
+	holder := &logSettingHolder{}
+	holder.setting.Store(&logSetting{logger: NopLogger{}})
+
+	return holder
+}
+
+func (h *logSettingHolder) logger() Logger {
+	return h.setting.Load().logger
+}
+
+func (h *logSettingHolder) slowListenerThreshold() time.Duration {
+	return h.setting.Load().slowListenerThreshold
+}
+
+// SetLogger
+//
+// Replaces the Logger of the context. A nil logger silences the context.
+func (ctx *ApplicationContext) SetLogger(logger Logger) {
+	if logger == nil {
+		logger = NopLogger{}
+	}
+
+	for {
+		prev := ctx.logSetting.setting.Load()
+		next := &logSetting{logger: logger, slowListenerThreshold: prev.slowListenerThreshold}
+		if ctx.logSetting.setting.CompareAndSwap(prev, next) {
+			return
+		}
+	}
+}
+
+// SetSlowListenerThreshold
+//
+// Listener executions taking longer than the threshold are logged as slow handlers.
+// 0 disables the slow handler records.
+func (ctx *ApplicationContext) SetSlowListenerThreshold(threshold time.Duration) {
+	for {
+		prev := ctx.logSetting.setting.Load()
+		next := &logSetting{logger: prev.logger, slowListenerThreshold: threshold}
+		if ctx.logSetting.setting.CompareAndSwap(prev, next) {
+			return
+		}
+	}
+}
+
+func (ctx *ApplicationContext) logger() Logger {
+	return ctx.logSetting.logger()
+}
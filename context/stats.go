@@ -0,0 +1,261 @@
+package context
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets
+//
+// The upper bounds of the latency histograms.
+var DefaultLatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Stats
+//
+// A snapshot of the runtime statistics of the ApplicationContext.
+type Stats struct {
+	Status    ApplicationStatus
+	Queues    QueueStats
+	Workers   WorkerStats
+	Autoscale AutoscaleStats
+	// Events is the sum of all the event counters.
+	Events EventCounters
+	// EventTypes holds the event counters per event type (keyed by reflect.Type.String()).
+	EventTypes map[string]EventCounters
+	// Listeners holds the statistics per event listener in registration order.
+	Listeners []ListenerStats
+	// QueueWait is the time from Trigger to the start of the listener execution.
+	QueueWait Histogram
+	// Execution is the time spent in the listener's Trigger.
+	Execution Histogram
+}
+
+type QueueStats struct {
+	Dispense QueueDepth
+	Process  QueueDepth
+	After    QueueDepth
+}
+
+type QueueDepth struct {
+	Len int
+	Cap int
+}
+
+type WorkerStats struct {
+	Dispense PoolStats
+	Process  PoolStats
+	After    PoolStats
+}
+
+type PoolStats struct {
+	// Size is the target number of workers.
+	Size int
+	// Running is the number of workers alive. It differs from Size while the pool is being resized.
+	Running int
+	Busy    int
+	Idle    int
+}
+
+// EventCounters
+//
+// The totals of the event sets.
+//   - Triggered: accepted by the ApplicationContext
+//   - Succeeded: the listener returned nil
+//   - Failed: the listener returned an error
+//   - Canceled: canceled with entity.EventContext before execution
+//   - Dropped: accepted but never delivered to the listener
+type EventCounters struct {
+	Triggered uint64
+	Succeeded uint64
+	Failed    uint64
+	Canceled  uint64
+	Dropped   uint64
+}
+
+func (c EventCounters) add(other EventCounters) EventCounters {
+	return EventCounters{
+		Triggered: c.Triggered + other.Triggered,
+		Succeeded: c.Succeeded + other.Succeeded,
+		Failed:    c.Failed + other.Failed,
+		Canceled:  c.Canceled + other.Canceled,
+		Dropped:   c.Dropped + other.Dropped,
+	}
+}
+
+type ListenerStats struct {
+	ID        string
+	EventType string
+	EventCounters
+	QueueWait Histogram
+	Execution Histogram
+}
+
+// Histogram
+//
+// A latency histogram. Counts[i] is the number of observations less than or equal to Buckets[i] (not cumulative),
+// and the last element of Counts is the number of observations greater than every bucket.
+type Histogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+func (h Histogram) add(other Histogram) Histogram {
+	if h.Counts == nil {
+		h.Buckets = other.Buckets
+		h.Counts = make([]uint64, len(other.Counts))
+	}
+
+	counts := make([]uint64, len(h.Counts))
+	for i := range counts {
+		counts[i] = h.Counts[i] + other.Counts[i]
+	}
+
+	return Histogram{
+		Buckets: h.Buckets,
+		Counts:  counts,
+		Count:   h.Count + other.Count,
+		Sum:     h.Sum + other.Sum,
+	}
+}
+
+// eventCounters is the lock-free counterpart of EventCounters.
+type eventCounters struct {
+	triggered atomic.Uint64
+	succeeded atomic.Uint64
+	failed    atomic.Uint64
+	canceled  atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+func (c *eventCounters) snapshot() EventCounters {
+	return EventCounters{
+		Triggered: c.triggered.Load(),
+		Succeeded: c.succeeded.Load(),
+		Failed:    c.failed.Load(),
+		Canceled:  c.canceled.Load(),
+		Dropped:   c.dropped.Load(),
+	}
+}
+
+// histogram is the lock-free counterpart of Histogram.
+type histogram struct {
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]atomic.Uint64, len(DefaultLatencyBuckets)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := sort.Search(len(DefaultLatencyBuckets), func(i int) bool {
+		return d <= DefaultLatencyBuckets[i]
+	})
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *histogram) snapshot() Histogram {
+	counts := make([]uint64, len(h.counts))
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+	}
+
+	return Histogram{
+		Buckets: DefaultLatencyBuckets,
+		Counts:  counts,
+		Count:   h.count.Load(),
+		Sum:     time.Duration(h.sum.Load()),
+	}
+}
+
+type listenerStats struct {
+	counters  eventCounters
+	queueWait *histogram
+	execution *histogram
+}
+
+func newListenerStats() *listenerStats {
+	return &listenerStats{
+		queueWait: newHistogram(),
+		execution: newHistogram(),
+	}
+}
+
+// Stats
+//
+// Returns a snapshot of the runtime statistics.
+// It only reads atomic counters, so it is cheap enough to be called on every scrape.
+func (ctx *ApplicationContext) Stats() Stats {
+	stats := Stats{
+		Status: ctx.Status(),
+		Queues: QueueStats{
+			Dispense: queueDepth(ctx.eventListenerDispenseChannel.DispenseChannel),
+			Process:  queueDepth(ctx.eventChannel.Channel),
+			After:    queueDepth(ctx.eventChannel.AfterChannel),
+		},
+		Workers: WorkerStats{
+			Dispense: poolStats(ctx.dispensePool),
+			Process:  poolStats(ctx.processPool),
+			After:    poolStats(ctx.afterPool),
+		},
+		Autoscale:  ctx.AutoscaleStats(),
+		EventTypes: map[string]EventCounters{},
+	}
+
+	unregistered := ctx.unregisteredStats
+	stats.Events = unregistered.counters.snapshot()
+	stats.QueueWait = unregistered.queueWait.snapshot()
+	stats.Execution = unregistered.execution.snapshot()
+
+	for _, registration := range ctx.registrations() {
+		listener := ListenerStats{
+			ID:            registration.ID,
+			EventType:     typeName(registration.EventType),
+			EventCounters: registration.stats.counters.snapshot(),
+			QueueWait:     registration.stats.queueWait.snapshot(),
+			Execution:     registration.stats.execution.snapshot(),
+		}
+
+		stats.Listeners = append(stats.Listeners, listener)
+		stats.EventTypes[listener.EventType] = stats.EventTypes[listener.EventType].add(listener.EventCounters)
+		stats.Events = stats.Events.add(listener.EventCounters)
+		stats.QueueWait = stats.QueueWait.add(listener.QueueWait)
+		stats.Execution = stats.Execution.add(listener.Execution)
+	}
+
+	return stats
+}
+
+func queueDepth[T any](queue *EventQueue[T]) QueueDepth {
+	return QueueDepth{Len: queue.Len(), Cap: queue.Cap()}
+}
+
+func poolStats[T any](pool *workerPool[T]) PoolStats {
+	running := int(pool.running.Load())
+	busy := int(pool.busy.Load())
+
+	return PoolStats{
+		Size:    pool.Size(),
+		Running: running,
+		Busy:    busy,
+		Idle:    running - busy,
+	}
+}
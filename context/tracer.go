@@ -0,0 +1,71 @@
+package context
+
+import (
+	"context"
+	"time"
+)
+
+// Tracer
+//
+// A hook that starts a span for every event listener execution.
+// (See the tracing package for the in-memory implementation and tracing/oteltracing for OpenTelemetry.)
+type Tracer interface {
+	// StartListenerSpan
+	//
+	// Starts the span of a listener execution.
+	// triggerContext is the context.Context the event has been triggered with, so the span can be linked to the span in it.
+	// The returned context.Context is passed to the listener if it implements entity.ContextEventListener.
+	StartListenerSpan(triggerContext context.Context, info SpanInfo) (context.Context, Span)
+}
+
+// Span
+//
+// A span started by Tracer. End is called exactly once when the listener execution has finished.
+type Span interface {
+	End(outcome SpanOutcome, err error)
+}
+
+// SpanInfo
+//
+// The attributes of a listener execution.
+type SpanInfo struct {
+	// EventID is the ID of the metadata of the event.
+	EventID   string
+	EventType string
+	Listener  string
+	// Attempt starts from 1.
+	Attempt int
+	// TriggeredAt is the time the event has been accepted.
+	TriggeredAt time.Time
+}
+
+type SpanOutcome string
+
+const (
+	SpanOutcomeSucceeded SpanOutcome = "succeeded"
+	SpanOutcomeFailed    SpanOutcome = "failed"
+	SpanOutcomePanicked  SpanOutcome = "panicked"
+)
+
+type tracerHolder struct {
+	tracer Tracer
+}
+
+// SetTracer
+//
+// Replaces the Tracer of the context. A nil tracer disables tracing, which is the default.
+func (ctx *ApplicationContext) SetTracer(tracer Tracer) {
+	ctx.tracer.Store(&tracerHolder{tracer: tracer})
+}
+
+// currentTracer
+//
+// Returns the Tracer of the context, or nil if tracing is disabled.
+func (ctx *ApplicationContext) currentTracer() Tracer {
+	holder := ctx.tracer.Load()
+	if holder == nil {
+		return nil
+	}
+
+	return holder.tracer
+}
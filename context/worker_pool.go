@@ -0,0 +1,124 @@
+package context
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// workerPool
+//
+// A group of goroutines that receive values from an EventQueue and handle them.
+// The number of workers can be changed while the pool is running.
+type workerPool[T any] struct {
+	// name identifies the pool in the log records.
+	name   string
+	logger func() Logger
+	queue  *EventQueue[T]
+	handle func(value T)
+	// lock guards size.
+	lock sync.Mutex
+	// size is the target number of workers.
+	size int
+	// running is the number of workers currently alive.
+	running atomic.Int32
+	// busy is the number of workers currently handling a value.
+	busy atomic.Int32
+	// stop
+	//
+	// Each value received from it ends one worker.
+	// Workers only receive it between two values, so the value being handled is never interrupted.
+	stop chan struct{}
+}
+
+func newWorkerPool[T any](name string, size int, queue *EventQueue[T], handle func(value T), logger func() Logger) *workerPool[T] {
+	return &workerPool[T]{
+		name:   name,
+		logger: logger,
+		queue:  queue,
+		handle: handle,
+		size:   size,
+		stop:   make(chan struct{}),
+	}
+}
+
+// start
+//
+// Spawns the workers. They end when done is closed.
+func (p *workerPool[T]) start(done <-chan struct{}) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i := 0; i < p.size; i++ {
+		p.spawn(done)
+	}
+}
+
+// resize
+//
+// Changes the number of workers.
+// Extra workers are ended after they finish the value they are handling.
+func (p *workerPool[T]) resize(done <-chan struct{}, size int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	diff := size - p.size
+	p.size = size
+
+	for i := 0; i < diff; i++ {
+		p.spawn(done)
+	}
+
+	if diff < 0 {
+		go func(n int) {
+			for i := 0; i < n; i++ {
+				select {
+				case p.stop <- struct{}{}:
+				case <-done:
+					return
+				}
+			}
+		}(-diff)
+	}
+}
+
+// Size
+//
+// Returns the target number of workers.
+func (p *workerPool[T]) Size() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.size
+}
+
+func (p *workerPool[T]) spawn(done <-chan struct{}) {
+	p.running.Add(1)
+
+	go func() {
+		defer p.running.Add(-1)
+
+	receiverLoop:
+		for {
+			channel, resized := p.queue.receiver()
+
+		selectLoop:
+			for {
+				select {
+				case <-done:
+					break receiverLoop
+				case <-p.stop:
+					break receiverLoop
+				case <-resized:
+					break selectLoop
+				case queued := <-channel:
+					p.queue.observeReceive(queued.queuedAt)
+					p.busy.Add(1)
+					p.handle(queued.value)
+					p.busy.Add(-1)
+				}
+			}
+		}
+
+		p.logger().Debug("eventx worker ended", "pool", p.name)
+	}()
+}
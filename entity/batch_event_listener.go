@@ -0,0 +1,259 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBatchMaxSize = 100
+	DefaultBatchMaxWait = 100 * time.Millisecond
+)
+
+// BatchEventListener
+//
+// Processes the events of type E in bulk. (See BatchOptions)
+//
+// If TriggerBatch returns BatchErrors with an error per entity, the failure is reported per item;
+// any other error fails every event of the batch.
+// A listener may also implement Then(entity E) and Catch(err error), which are called per event.
+type BatchEventListener[E any] interface {
+	TriggerBatch(entities []E) error
+}
+
+type TriggerBatchFunc[E any] func(entities []E) error
+
+func (f TriggerBatchFunc[E]) TriggerBatch(entities []E) error {
+	return f(entities)
+}
+
+// BatchErrors
+//
+// The errors of the entities of a batch, in the order of the entities. A nil error means the entity has succeeded.
+type BatchErrors []error
+
+func (e BatchErrors) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e {
+		if err != nil {
+			failed += 1
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	return fmt.Sprintf("%d of %d events of the batch failed: %v", failed, len(e), first)
+}
+
+// BatchOptions
+//
+// A batch is triggered when it holds MaxSize events or when its first event has waited MaxWait.
+type BatchOptions struct {
+	// MaxSize is DefaultBatchMaxSize when it is 0.
+	MaxSize int
+	// MaxWait is DefaultBatchMaxWait when it is 0.
+	MaxWait time.Duration
+}
+
+// NewBatchEventSetFactory
+//
+// Returns the EventSetFactory of the batch listener. The event sets it creates share one batch.
+func NewBatchEventSetFactory[E any](listener BatchEventListener[E], options BatchOptions) EventSetFactory {
+	if options.MaxSize <= 0 {
+		options.MaxSize = DefaultBatchMaxSize
+	}
+	if options.MaxWait <= 0 {
+		options.MaxWait = DefaultBatchMaxWait
+	}
+	b := &batcher[E]{listener: listener, options: options}
+
+	return func(entity any, metadata Metadata) (EventSet, bool) {
+		specified, ok := entity.(E)
+		if !ok {
+			return nil, false
+		}
+
+		ctx := NewEventRunnerContext()
+		ctx.ID = metadata.ID
+
+		return &batchEventSet[E]{
+			batcher:  b,
+			entity:   specified,
+			metadata: metadata,
+			ctx:      ctx,
+		}, true
+	}
+}
+
+// batchEventSet
+//
+// A DeferredEventSet which adds the entity to the batch of its listener.
+// The first event set of a batch is resumed with the execution of the listener, and the others with their completion.
+type batchEventSet[E any] struct {
+	batcher  *batcher[E]
+	entity   E
+	metadata Metadata
+	ctx      *EventRunnerContextImpl
+	resume   func(runner EventRunner)
+}
+
+func (s *batchEventSet[E]) Runner() func() {
+	return s.RunnerContext(context.Background())
+}
+
+// RunnerContext
+//
+// Adds the entity to the batch outside the event process pool: the batch is executed by the goroutine completing it.
+func (s *batchEventSet[E]) RunnerContext(ctx context.Context) func() {
+	s.RunnerDeferred(ctx, resumeInline)
+	return nil
+}
+
+func (s *batchEventSet[E]) RunnerDeferred(_ context.Context, resume func(runner EventRunner)) {
+	s.resume = resume
+	s.batcher.add(s)
+}
+
+func (s *batchEventSet[E]) Context() *EventRunnerContextImpl {
+	return s.ctx
+}
+
+// complete
+//
+// Records the result of the entity and returns its after runner (then, catch).
+func (s *batchEventSet[E]) complete(err error) func() {
+	s.ctx.SetResult(err)
+
+	var after func()
+	if err != nil {
+		if el, ok := s.batcher.listener.(interface{ Catch(err error) }); ok {
+			after = func() {
+				el.Catch(err)
+			}
+		}
+	} else if el, ok := s.batcher.listener.(interface{ Then(entity E) }); ok {
+		after = func() {
+			el.Then(s.entity)
+		}
+	}
+
+	return after
+}
+
+// batcher
+//
+// Accumulates the event sets of a batch listener.
+type batcher[E any] struct {
+	listener BatchEventListener[E]
+	options  BatchOptions
+	lock     sync.Mutex
+	sets     []*batchEventSet[E]
+	// generation identifies the current batch, so that the timer of a triggered batch does not trigger the next one.
+	generation uint64
+	timer      *time.Timer
+}
+
+func (b *batcher[E]) add(set *batchEventSet[E]) {
+	b.lock.Lock()
+	b.sets = append(b.sets, set)
+	if len(b.sets) == 1 {
+		generation := b.generation
+		b.timer = time.AfterFunc(b.options.MaxWait, func() {
+			b.flush(generation)
+		})
+	}
+	if len(b.sets) < b.options.MaxSize {
+		b.lock.Unlock()
+		return
+	}
+
+	b.timer.Stop()
+	sets := b.take()
+	b.lock.Unlock()
+
+	b.trigger(sets)
+}
+
+func (b *batcher[E]) flush(generation uint64) {
+	b.lock.Lock()
+	if generation != b.generation || len(b.sets) == 0 {
+		b.lock.Unlock()
+		return
+	}
+	sets := b.take()
+	b.lock.Unlock()
+
+	b.trigger(sets)
+}
+
+func (b *batcher[E]) take() []*batchEventSet[E] {
+	sets := b.sets
+	b.sets = nil
+	b.generation += 1
+
+	return sets
+}
+
+// trigger
+//
+// Resumes the first event set of the batch with the execution of the listener,
+// which resumes the other event sets once their results have been recorded.
+func (b *batcher[E]) trigger(sets []*batchEventSet[E]) {
+	sets[0].resume(func() func() {
+		afterRunners := b.execute(sets)
+		for i, set := range sets[1:] {
+			afterRunner := afterRunners[i+1]
+			set.resume(func() func() {
+				return afterRunner
+			})
+		}
+
+		return afterRunners[0]
+	})
+}
+
+// execute
+//
+// Executes the listener with the entities of the batch and returns the after runners of the event sets.
+func (b *batcher[E]) execute(sets []*batchEventSet[E]) []func() {
+	entities := make([]E, len(sets))
+	for i, set := range sets {
+		entities[i] = set.entity
+	}
+
+	err := b.triggerBatch(entities)
+
+	afterRunners := make([]func(), len(sets))
+	var itemErrs BatchErrors
+	if errors.As(err, &itemErrs) && len(itemErrs) == len(sets) {
+		for i, set := range sets {
+			afterRunners[i] = set.complete(itemErrs[i])
+		}
+		return afterRunners
+	}
+
+	for i, set := range sets {
+		afterRunners[i] = set.complete(err)
+	}
+
+	return afterRunners
+}
+
+// triggerBatch
+//
+// A panic of the listener is returned as *PanicError.
+func (b *batcher[E]) triggerBatch(entities []E) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return b.listener.TriggerBatch(entities)
+}
@@ -0,0 +1,21 @@
+package entity
+
+import "context"
+
+func BuildContextEventListener[E any](trigger func(ctx context.Context, entity E) error) EventListener[E] {
+	return &contextEventListener[E]{
+		InnerTrigger: trigger,
+	}
+}
+
+type contextEventListener[E any] struct {
+	InnerTrigger TriggerContextFunc[E]
+}
+
+func (l *contextEventListener[E]) Trigger(entity E) error {
+	return l.InnerTrigger(context.Background(), entity)
+}
+
+func (l *contextEventListener[E]) TriggerContext(ctx context.Context, entity E) error {
+	return l.InnerTrigger(ctx, entity)
+}
@@ -0,0 +1,119 @@
+package entity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Metadata
+//
+// The information the bus attaches to every triggered entity.
+type Metadata struct {
+	// ID identifies the triggered event. Every listener of the event receives the same ID.
+	ID string
+	// OccurredAt is the time the event has occurred. It is the time of the trigger unless it is given.
+	OccurredAt time.Time
+	// EnqueuedAt is the time the bus has accepted the event.
+	EnqueuedAt time.Time
+	Headers    map[string]string
+	// CorrelationID is shared by the events of a causal chain. It is the ID of the first event of the chain.
+	CorrelationID string
+	// CausationID is the ID of the event whose listener has triggered this event. It is empty for the first event of the chain.
+	CausationID string
+}
+
+// Envelope
+//
+// An entity wrapped with its Metadata.
+type Envelope[E any] struct {
+	Metadata
+	Entity E
+}
+
+// NewEventID
+//
+// Returns a random UUID (version 4).
+func NewEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+
+	return string(buf)
+}
+
+// Complete
+//
+// Fills the blank fields of the metadata.
+// If parent is given (the metadata of the event being processed), the correlation ID is inherited from it
+// and the causation ID becomes the parent's ID.
+func (m Metadata) Complete(parent *Metadata, now time.Time) Metadata {
+	if m.ID == "" {
+		m.ID = NewEventID()
+	}
+	if m.OccurredAt.IsZero() {
+		m.OccurredAt = now
+	}
+	if m.EnqueuedAt.IsZero() {
+		m.EnqueuedAt = now
+	}
+	if parent != nil {
+		if m.CorrelationID == "" {
+			m.CorrelationID = parent.CorrelationID
+		}
+		if m.CausationID == "" {
+			m.CausationID = parent.ID
+		}
+	}
+	if m.CorrelationID == "" {
+		m.CorrelationID = m.ID
+	}
+
+	return m
+}
+
+// clone
+//
+// Returns a copy that does not share the headers.
+func (m Metadata) clone() Metadata {
+	if m.Headers != nil {
+		headers := make(map[string]string, len(m.Headers))
+		for key, value := range m.Headers {
+			headers[key] = value
+		}
+		m.Headers = headers
+	}
+
+	return m
+}
+
+type metadataContextKey struct{}
+
+// ContextWithMetadata
+//
+// Returns a context carrying the metadata. The listeners receive their event's metadata this way,
+// so the events triggered with the context inherit the correlation ID.
+func ContextWithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataContextKey{}, metadata)
+}
+
+// MetadataFromContext
+//
+// Returns the metadata carried by the context.
+func MetadataFromContext(ctx context.Context) (Metadata, bool) {
+	metadata, ok := ctx.Value(metadataContextKey{}).(Metadata)
+	return metadata, ok
+}
@@ -0,0 +1,21 @@
+package entity
+
+import "context"
+
+func BuildEnvelopeEventListener[E any](trigger func(ctx context.Context, envelope Envelope[E]) error) EventListener[E] {
+	return &envelopeEventListener[E]{
+		InnerTrigger: trigger,
+	}
+}
+
+type envelopeEventListener[E any] struct {
+	InnerTrigger TriggerEnvelopeFunc[E]
+}
+
+func (l *envelopeEventListener[E]) Trigger(entity E) error {
+	return l.InnerTrigger(context.Background(), Envelope[E]{Entity: entity})
+}
+
+func (l *envelopeEventListener[E]) TriggerEnvelope(ctx context.Context, envelope Envelope[E]) error {
+	return l.InnerTrigger(ctx, envelope)
+}
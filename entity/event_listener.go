@@ -1,5 +1,7 @@
 package entity
 
+import "context"
+
 type EventListener[E any] interface {
 	Trigger(entity E) error
 }
@@ -20,6 +22,26 @@ type CallbackEventListener[E any] interface {
 	CatchErrEventListener[E]
 }
 
+// ContextEventListener
+//
+// An EventListener that receives the context.Context of the execution.
+// The context carries the values (e.g. the span) of the context the event has been triggered with,
+// but it is not canceled when the triggering context is canceled.
+type ContextEventListener[E any] interface {
+	EventListener[E]
+	TriggerContext(ctx context.Context, entity E) error
+}
+
+// EnvelopeEventListener
+//
+// An EventListener that receives the entity wrapped with its Metadata.
+type EnvelopeEventListener[E any] interface {
+	EventListener[E]
+	TriggerEnvelope(ctx context.Context, envelope Envelope[E]) error
+}
+
 type TriggerFunc[E any] func(entity E) error
+type TriggerContextFunc[E any] func(ctx context.Context, entity E) error
+type TriggerEnvelopeFunc[E any] func(ctx context.Context, envelope Envelope[E]) error
 type ThenFunc[E any] func(entity E)
 type CatchFunc func(err error)
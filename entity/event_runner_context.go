@@ -6,16 +6,35 @@ type EventContext interface {
 	IsRunnable() bool
 	Cancel() bool
 	IsDone() bool
+	Err() error
+	EventID() string
+	// Finished is closed when the event has been processed (including then/catch), canceled or dropped.
+	Finished() <-chan struct{}
+	// IsSkipped reports whether the event listener has skipped the event. (See SkipEvent)
+	IsSkipped() bool
+	// IsSuperseded reports whether the event has been replaced by a later event of its key before being processed. (See Debounce, Throttle and Coalesce)
+	IsSuperseded() bool
 }
 
 type EventRunnerContextImpl struct {
 	sync.Mutex
 	Runnable bool
 	Done     bool
+	// Result is the error returned by the event listener.
+	Result error
+	// ID is the ID of the Metadata of the event.
+	ID string
+	// Skipped is set when the event listener has skipped the event.
+	Skipped bool
+	// Superseded is set when a later event has replaced the event.
+	Superseded bool
+	// finished is closed by Finish.
+	finished   chan struct{}
+	finishOnce sync.Once
 }
 
 func NewEventRunnerContext() *EventRunnerContextImpl {
-	return &EventRunnerContextImpl{Runnable: true, Done: false}
+	return &EventRunnerContextImpl{Runnable: true, Done: false, finished: make(chan struct{})}
 }
 
 func (c *EventRunnerContextImpl) IsRunnable() bool {
@@ -52,3 +71,77 @@ func (c *EventRunnerContextImpl) IsDone() bool {
 
 	return finished
 }
+
+func (c *EventRunnerContextImpl) Err() error {
+	var err error
+
+	c.Lock()
+	err = c.Result
+	c.Unlock()
+
+	return err
+}
+
+func (c *EventRunnerContextImpl) EventID() string {
+	return c.ID
+}
+
+func (c *EventRunnerContextImpl) Finished() <-chan struct{} {
+	return c.finished
+}
+
+// Finish
+//
+// Closes the Finished channel. (For internal usage within `eventx`)
+func (c *EventRunnerContextImpl) Finish() {
+	c.finishOnce.Do(func() {
+		close(c.finished)
+	})
+}
+
+// SetResult
+//
+// Records the error of the event. (For internal usage within `eventx`)
+func (c *EventRunnerContextImpl) SetResult(err error) {
+	c.Lock()
+	c.Result = err
+	c.Unlock()
+}
+
+func (c *EventRunnerContextImpl) IsSkipped() bool {
+	skipped := false
+
+	c.Lock()
+	skipped = c.Skipped
+	c.Unlock()
+
+	return skipped
+}
+
+// Skip
+//
+// Records that the event listener has skipped the event. (For internal usage within `eventx`)
+func (c *EventRunnerContextImpl) Skip() {
+	c.Lock()
+	c.Skipped = true
+	c.Unlock()
+}
+
+func (c *EventRunnerContextImpl) IsSuperseded() bool {
+	superseded := false
+
+	c.Lock()
+	superseded = c.Superseded
+	c.Unlock()
+
+	return superseded
+}
+
+// Supersede
+//
+// Records that a later event has replaced the event. (For internal usage within `eventx`)
+func (c *EventRunnerContextImpl) Supersede() {
+	c.Lock()
+	c.Superseded = true
+	c.Unlock()
+}
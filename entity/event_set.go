@@ -1,26 +1,127 @@
 package entity
 
+import (
+	"context"
+	"runtime/debug"
+	"time"
+)
+
 type EventSet interface {
 	Runner() func()
 	Context() *EventRunnerContextImpl
 }
 
+// ContextualEventSet
+//
+// An EventSet whose listener execution can receive a context.Context.
+type ContextualEventSet interface {
+	EventSet
+	RunnerContext(ctx context.Context) func()
+}
+
+// DeferredEventSet
+//
+// An EventSet whose listener execution is resumed later on the event process pool (e.g. the events of a batch).
+// RunnerDeferred is called instead of the runner and must not block: it calls resume once with the runner
+// executing the listener and returning its after runner (nil if none), and the pool queues that runner.
+type DeferredEventSet interface {
+	EventSet
+	RunnerDeferred(ctx context.Context, resume func(runner EventRunner))
+}
+
+// resumeInline
+//
+// Resumes a DeferredEventSet executed outside the event process pool, on the goroutine resuming it.
+func resumeInline(runner EventRunner) {
+	if afterRunner := runner(); afterRunner != nil {
+		afterRunner()
+	}
+}
+
+// QueueAwareEventSet
+//
+// An EventSet which is told about its queueing: Queued is called before it is queued,
+// and Dropped if it is not executed after all (it could not be queued or it has been canceled).
+type QueueAwareEventSet interface {
+	EventSet
+	Queued()
+	Dropped()
+}
+
+// RejectableEventSet
+//
+// An EventSet which can be completed with an error without executing its listener.
+type RejectableEventSet interface {
+	EventSet
+	// Reject records err as the result and returns the after runner calling Catch of the listener (nil if none).
+	Reject(err error) func()
+}
+
 type EventSetImpl[E any] struct {
 	EventListener EventListener[E]
 	Entity        E
+	Metadata      Metadata
 	Ctx           *EventRunnerContextImpl
 }
 
 func NewEventSet[E any](listener EventListener[E], entity E) EventSet {
+	return NewEnvelopeEventSet(listener, Envelope[E]{
+		Metadata: Metadata{}.Complete(nil, time.Now()),
+		Entity:   entity,
+	})
+}
+
+// EventSetFactory
+//
+// Creates the EventSet of a listener from an untyped entity.
+// It returns false if the entity is not of the listener's type.
+type EventSetFactory func(entity any, metadata Metadata) (EventSet, bool)
+
+// NewEventSetFactory
+//
+// Returns the EventSetFactory of the listener.
+func NewEventSetFactory[E any](listener EventListener[E]) EventSetFactory {
+	return func(entity any, metadata Metadata) (EventSet, bool) {
+		specified, ok := entity.(E)
+		if !ok {
+			return nil, false
+		}
+
+		return NewEnvelopeEventSet(listener, Envelope[E]{Metadata: metadata, Entity: specified}), true
+	}
+}
+
+// NewEnvelopeEventSet
+//
+// Creates an EventSet whose listener receives the metadata of the envelope.
+func NewEnvelopeEventSet[E any](listener EventListener[E], envelope Envelope[E]) EventSet {
+	ctx := NewEventRunnerContext()
+	ctx.ID = envelope.ID
+
 	return &EventSetImpl[E]{
 		EventListener: listener,
-		Entity:        entity,
-		Ctx:           NewEventRunnerContext(),
+		Entity:        envelope.Entity,
+		Metadata:      envelope.Metadata,
+		Ctx:           ctx,
 	}
 }
 
 func (s *EventSetImpl[E]) Runner() func() {
-	err := s.EventListener.Trigger(s.Entity)
+	return s.RunnerContext(context.Background())
+}
+
+// RunnerContext
+//
+// Works like Runner. ctx, carrying the metadata of the event set, is passed to the listener
+// if it is a ContextEventListener or an EnvelopeEventListener.
+func (s *EventSetImpl[E]) RunnerContext(ctx context.Context) func() {
+	ctx, skipped := contextWithSkip(ctx)
+	err := s.trigger(ctx)
+	s.Ctx.SetResult(err)
+	if skipped.Load() {
+		s.Ctx.Skip()
+		return nil
+	}
 	if err != nil {
 		el, ok := s.EventListener.(CatchErrEventListener[E])
 
@@ -43,6 +144,38 @@ func (s *EventSetImpl[E]) Runner() func() {
 	return nil
 }
 
+func (s *EventSetImpl[E]) Reject(err error) func() {
+	s.Ctx.SetResult(err)
+	if el, ok := s.EventListener.(CatchErrEventListener[E]); ok {
+		return func() {
+			el.Catch(err)
+		}
+	}
+
+	return nil
+}
+
+// trigger
+//
+// Executes the event listener. A panic of the listener is returned as *PanicError.
+func (s *EventSetImpl[E]) trigger(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	switch el := s.EventListener.(type) {
+	case EnvelopeEventListener[E]:
+		metadata := s.Metadata.clone()
+		return el.TriggerEnvelope(ContextWithMetadata(ctx, metadata), Envelope[E]{Metadata: metadata, Entity: s.Entity})
+	case ContextEventListener[E]:
+		return el.TriggerContext(ContextWithMetadata(ctx, s.Metadata.clone()), s.Entity)
+	default:
+		return s.EventListener.Trigger(s.Entity)
+	}
+}
+
 func (s *EventSetImpl[E]) Context() *EventRunnerContextImpl {
 	return s.Ctx
 }
@@ -0,0 +1,478 @@
+package entity
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Operator
+//
+// Changes how the events of a listener are executed, by wrapping the EventSetFactory of the listener.
+// The operators of a registration are applied in order. (See Debounce, Throttle and Coalesce)
+type Operator[E any] func(factory EventSetFactory) EventSetFactory
+
+// KeyFunc
+//
+// Returns the key the events are grouped by.
+type KeyFunc[E any] func(entity E) string
+
+// Debounce
+//
+// Executes the listener once per key, with the latest event, after no event of the key has been triggered for d.
+// The earlier events of the burst are superseded. (See EventContext.IsSuperseded)
+func Debounce[E any](d time.Duration, key KeyFunc[E]) Operator[E] {
+	return func(factory EventSetFactory) EventSetFactory {
+		debouncer := &debouncer{d: d, keys: map[string]*debounceKey{}}
+
+		return wrapFactory(factory, key, debouncer.schedule)
+	}
+}
+
+// Throttle
+//
+// Executes the listener at most once per interval per key.
+// The first event of a key is executed immediately; the latest event triggered during the interval is executed at its end,
+// and the other events of the interval are superseded. (See EventContext.IsSuperseded)
+func Throttle[E any](interval time.Duration, key KeyFunc[E]) Operator[E] {
+	return func(factory EventSetFactory) EventSetFactory {
+		throttler := &throttler{interval: interval, keys: map[string]*throttleKey{}}
+
+		return wrapFactory(factory, key, throttler.schedule)
+	}
+}
+
+// Coalesce
+//
+// While an event of a key is still queued, a new event of the key replaces it:
+// the queued event is superseded when it is dequeued (see EventContext.IsSuperseded), and the new event is executed.
+// An event replaces the others once it has been queued, and stops replacing them if it is canceled before its execution.
+func Coalesce[E any](key KeyFunc[E]) Operator[E] {
+	return func(factory EventSetFactory) EventSetFactory {
+		queued := &coalescer{sets: map[string][]*coalesceEventSet{}}
+
+		return func(entity any, metadata Metadata) (EventSet, bool) {
+			inner, ok := factory(entity, metadata)
+			if !ok {
+				return nil, false
+			}
+
+			set := &coalesceEventSet{inner: inner, key: key(entity.(E)), queued: queued}
+			if _, ok := inner.(DeferredEventSet); ok {
+				return &deferredCoalesceEventSet{coalesceEventSet: set}, true
+			}
+
+			return set, true
+		}
+	}
+}
+
+// runEventSet
+//
+// Executes the event set and returns its after runner.
+func runEventSet(ctx context.Context, set EventSet) func() {
+	if contextual, ok := set.(ContextualEventSet); ok {
+		return contextual.RunnerContext(ctx)
+	}
+
+	return set.Runner()
+}
+
+// resumeEventSet
+//
+// Resumes an operator's event set with the execution of the wrapped event set.
+// A wrapped DeferredEventSet is resumed by itself.
+func resumeEventSet(ctx context.Context, set EventSet, resume func(runner EventRunner)) {
+	if deferred, ok := set.(DeferredEventSet); ok {
+		deferred.RunnerDeferred(ctx, resume)
+		return
+	}
+
+	resume(func() func() {
+		return runEventSet(ctx, set)
+	})
+}
+
+// rejectEventSet
+//
+// Completes the event set with err without executing it, and returns its after runner.
+func rejectEventSet(set EventSet, err error) func() {
+	if rejectable, ok := set.(RejectableEventSet); ok {
+		return rejectable.Reject(err)
+	}
+	set.Context().SetResult(err)
+
+	return nil
+}
+
+// queuedEventSet
+//
+// Forwards the queueing of an operator's event set to the wrapped event set.
+func queuedEventSet(set EventSet) {
+	if aware, ok := set.(QueueAwareEventSet); ok {
+		aware.Queued()
+	}
+}
+
+// droppedEventSet
+//
+// Forwards the drop of an operator's event set to the wrapped event set.
+func droppedEventSet(set EventSet) {
+	if aware, ok := set.(QueueAwareEventSet); ok {
+		aware.Dropped()
+	}
+}
+
+// wrapFactory
+//
+// Wraps the event sets of the factory into deferredEventSets handed over to schedule. key may be nil.
+func wrapFactory[E any](factory EventSetFactory, key KeyFunc[E], schedule func(set *deferredEventSet)) EventSetFactory {
+	var seq atomic.Uint64
+
+	return func(entity any, metadata Metadata) (EventSet, bool) {
+		inner, ok := factory(entity, metadata)
+		if !ok {
+			return nil, false
+		}
+
+		set := &deferredEventSet{
+			inner:    inner,
+			entity:   entity,
+			metadata: metadata,
+			seq:      seq.Add(1),
+			schedule: schedule,
+		}
+		if key != nil {
+			set.key = key(entity.(E))
+		}
+
+		return set, true
+	}
+}
+
+// deferredEventSet
+//
+// A DeferredEventSet handed over to an operator, which either executes or supersedes it.
+// The execution is resumed on the event process pool, not on the timer of the operator.
+type deferredEventSet struct {
+	inner    EventSet
+	entity   any
+	metadata Metadata
+	key      string
+	// seq orders the events in the order they have been triggered, which may differ from the order of their execution.
+	seq      uint64
+	schedule func(set *deferredEventSet)
+	ctx      context.Context
+	resume   func(runner EventRunner)
+}
+
+func (s *deferredEventSet) Runner() func() {
+	return s.RunnerContext(context.Background())
+}
+
+// RunnerContext
+//
+// Hands the event over to the operator outside the event process pool: it is executed by the goroutine resuming it.
+func (s *deferredEventSet) RunnerContext(ctx context.Context) func() {
+	s.RunnerDeferred(ctx, resumeInline)
+	return nil
+}
+
+func (s *deferredEventSet) RunnerDeferred(ctx context.Context, resume func(runner EventRunner)) {
+	s.ctx, s.resume = ctx, resume
+	s.schedule(s)
+}
+
+func (s *deferredEventSet) Context() *EventRunnerContextImpl {
+	return s.inner.Context()
+}
+
+func (s *deferredEventSet) Queued() {
+	queuedEventSet(s.inner)
+}
+
+func (s *deferredEventSet) Dropped() {
+	droppedEventSet(s.inner)
+}
+
+// execute
+//
+// Executes the wrapped event set with the context of the execution of s.
+func (s *deferredEventSet) execute() {
+	resumeEventSet(s.ctx, s.inner, s.resume)
+}
+
+// reject
+//
+// Completes the event with err without executing it.
+func (s *deferredEventSet) reject(err error) {
+	s.resume(func() func() {
+		return s.Reject(err)
+	})
+}
+
+func (s *deferredEventSet) Reject(err error) func() {
+	return rejectEventSet(s.inner, err)
+}
+
+// supersede
+//
+// Completes the event without executing it.
+func (s *deferredEventSet) supersede() {
+	s.Context().Supersede()
+	s.resume(func() func() {
+		return nil
+	})
+}
+
+// debouncer
+//
+// Holds the latest event of every key until its quiet time has passed.
+type debouncer struct {
+	d    time.Duration
+	lock sync.Mutex
+	keys map[string]*debounceKey
+}
+
+type debounceKey struct {
+	latest *deferredEventSet
+	timer  *time.Timer
+}
+
+func (d *debouncer) schedule(set *deferredEventSet) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	key, ok := d.keys[set.key]
+	if ok {
+		if set.seq < key.latest.seq {
+			set.supersede()
+			return
+		}
+		key.timer.Stop()
+		key.latest.supersede()
+	} else {
+		key = &debounceKey{}
+		d.keys[set.key] = key
+	}
+
+	key.latest = set
+	key.timer = time.AfterFunc(d.d, func() {
+		d.fire(set)
+	})
+}
+
+func (d *debouncer) fire(set *deferredEventSet) {
+	d.lock.Lock()
+	key, ok := d.keys[set.key]
+	if !ok || key.latest != set {
+		// superseded while the timer was firing
+		d.lock.Unlock()
+		return
+	}
+	delete(d.keys, set.key)
+	d.lock.Unlock()
+
+	set.execute()
+}
+
+// throttler
+//
+// Executes the first event of every key and holds the latest one of the following interval.
+type throttler struct {
+	interval time.Duration
+	lock     sync.Mutex
+	keys     map[string]*throttleKey
+}
+
+type throttleKey struct {
+	// executed is the seq of the last executed event of the key.
+	executed uint64
+	pending  *deferredEventSet
+}
+
+func (t *throttler) schedule(set *deferredEventSet) {
+	t.lock.Lock()
+	key, ok := t.keys[set.key]
+	if ok {
+		if set.seq < key.executed || (key.pending != nil && set.seq < key.pending.seq) {
+			t.lock.Unlock()
+			set.supersede()
+			return
+		}
+		if key.pending != nil {
+			key.pending.supersede()
+		}
+		key.pending = set
+		t.lock.Unlock()
+		return
+	}
+
+	t.keys[set.key] = &throttleKey{executed: set.seq}
+	time.AfterFunc(t.interval, func() {
+		t.release(set.key)
+	})
+	t.lock.Unlock()
+
+	set.execute()
+}
+
+// release
+//
+// Ends the interval of the key: the pending event is executed and starts the next interval.
+func (t *throttler) release(name string) {
+	t.lock.Lock()
+	key := t.keys[name]
+	pending := key.pending
+	if pending == nil {
+		delete(t.keys, name)
+		t.lock.Unlock()
+		return
+	}
+	key.executed, key.pending = pending.seq, nil
+	time.AfterFunc(t.interval, func() {
+		t.release(name)
+	})
+	t.lock.Unlock()
+
+	pending.execute()
+}
+
+// coalescer
+//
+// Holds the queued events of every key, in the order they have been queued.
+type coalescer struct {
+	lock sync.Mutex
+	sets map[string][]*coalesceEventSet
+}
+
+func (c *coalescer) enqueue(set *coalesceEventSet) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.sets[set.key] = append(c.sets[set.key], set)
+}
+
+// remove
+//
+// Removes the event set from the queued ones without superseding the others.
+func (c *coalescer) remove(set *coalesceEventSet) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.take(set)
+}
+
+// dequeue
+//
+// Returns whether the event set has been replaced: a later event of its key is still queued, or has been executed.
+// If it has not, the earlier events of its key are superseded by it.
+func (c *coalescer) dequeue(set *coalesceEventSet) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	index := c.take(set)
+	if set.superseded || index < 0 {
+		return set.superseded
+	}
+	if queued := c.sets[set.key]; index < len(queued) {
+		return true
+	}
+
+	for _, earlier := range c.sets[set.key] {
+		earlier.superseded = true
+	}
+	delete(c.sets, set.key)
+
+	return false
+}
+
+// take
+//
+// Removes the event set from the queued ones of its key and returns its index, or -1 if it is not queued.
+func (c *coalescer) take(set *coalesceEventSet) int {
+	queued := c.sets[set.key]
+	for i, candidate := range queued {
+		if candidate != set {
+			continue
+		}
+
+		queued = append(queued[:i:i], queued[i+1:]...)
+		if len(queued) == 0 {
+			delete(c.sets, set.key)
+		} else {
+			c.sets[set.key] = queued
+		}
+		return i
+	}
+
+	return -1
+}
+
+// coalesceEventSet
+//
+// An EventSet which is not executed if a later event of its key has been queued while it was queued.
+type coalesceEventSet struct {
+	inner  EventSet
+	key    string
+	queued *coalescer
+	// superseded is guarded by the lock of the coalescer.
+	superseded bool
+}
+
+func (s *coalesceEventSet) Runner() func() {
+	return s.RunnerContext(context.Background())
+}
+
+func (s *coalesceEventSet) RunnerContext(ctx context.Context) func() {
+	if s.queued.dequeue(s) {
+		s.Context().Supersede()
+		return nil
+	}
+
+	return runEventSet(ctx, s.inner)
+}
+
+func (s *coalesceEventSet) Context() *EventRunnerContextImpl {
+	return s.inner.Context()
+}
+
+func (s *coalesceEventSet) Queued() {
+	s.queued.enqueue(s)
+	queuedEventSet(s.inner)
+}
+
+func (s *coalesceEventSet) Dropped() {
+	s.queued.remove(s)
+	droppedEventSet(s.inner)
+}
+
+func (s *coalesceEventSet) Reject(err error) func() {
+	s.queued.remove(s)
+	return rejectEventSet(s.inner, err)
+}
+
+// deferredCoalesceEventSet
+//
+// A coalesceEventSet wrapping a DeferredEventSet, which is resumed by the wrapped event set instead of holding a worker.
+type deferredCoalesceEventSet struct {
+	*coalesceEventSet
+}
+
+func (s *deferredCoalesceEventSet) RunnerContext(ctx context.Context) func() {
+	s.RunnerDeferred(ctx, resumeInline)
+	return nil
+}
+
+func (s *deferredCoalesceEventSet) RunnerDeferred(ctx context.Context, resume func(runner EventRunner)) {
+	if s.queued.dequeue(s.coalesceEventSet) {
+		s.Context().Supersede()
+		resume(func() func() {
+			return nil
+		})
+		return
+	}
+
+	s.inner.(DeferredEventSet).RunnerDeferred(ctx, resume)
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "fmt"
+
+// PanicError
+//
+// The error an event listener execution results in when the listener panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("event listener panicked: %v", e.Value)
+}
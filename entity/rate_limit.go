@@ -0,0 +1,176 @@
+package entity
+
+import (
+	"context"
+	stderrors "errors"
+	"github.com/aivyss/eventx/errors"
+	"sync"
+	"time"
+)
+
+// RateLimiter
+//
+// A token bucket holding up to burst tokens, refilled at rate tokens per second.
+// A limiter can be shared by the listeners calling the same upstream. (See RateLimit)
+type RateLimiter struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter
+//
+// Creates a full bucket. A burst below 1 is 1, and a rate of 0 or less does not limit.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow
+//
+// Takes a token if one is available.
+func (l *RateLimiter) Allow() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rate <= 0 {
+		return true
+	}
+	l.refill(time.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens -= 1
+
+	return true
+}
+
+// Reserve
+//
+// Takes a token and returns how long the caller has to wait until the token is available.
+// The reservations are served in order. No token is taken and false is returned if the wait would exceed maxWait.
+func (l *RateLimiter) Reserve(maxWait time.Duration) (time.Duration, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rate <= 0 {
+		return 0, true
+	}
+	l.refill(time.Now())
+	if l.tokens >= 1 {
+		l.tokens -= 1
+		return 0, true
+	}
+
+	wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	if wait > maxWait {
+		return 0, false
+	}
+	l.tokens -= 1
+
+	return wait, true
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// RateLimitPolicy
+//
+// What happens to the events exceeding the rate of the limiter.
+type RateLimitPolicy int
+
+const DefaultRateLimitMaxWait = 10 * time.Second
+
+const (
+	// RateLimitWait
+	//
+	// The event waits for its token without holding a worker of the event process pool, and is executed by the pool.
+	// The events which would wait longer than the MaxWait of the options are rejected like RateLimitReject,
+	// or dead-lettered if the options have a DeadLetter function.
+	RateLimitWait RateLimitPolicy = iota
+	// RateLimitReject
+	//
+	// The event fails with errors.RateLimitedErr, which is passed to Catch of the listener.
+	RateLimitReject
+	// RateLimitDeadLetter
+	//
+	// The event is handed to the DeadLetter function of the options instead of the listener.
+	// It fails with errors.RateLimitedErr, and Catch of the listener is not called.
+	RateLimitDeadLetter
+)
+
+type RateLimitOptions[E any] struct {
+	Policy RateLimitPolicy
+	// MaxWait bounds the wait of RateLimitWait. It is DefaultRateLimitMaxWait when it is 0.
+	MaxWait time.Duration
+	// DeadLetter receives the events rejected with RateLimitDeadLetter. Its error is joined to the error of the event.
+	DeadLetter func(ctx context.Context, envelope Envelope[E]) error
+}
+
+// RateLimit
+//
+// Limits the executions of the listener with the token bucket of the limiter.
+// With RateLimitDeadLetter and no DeadLetter function, the events are rejected like RateLimitReject.
+func RateLimit[E any](limiter *RateLimiter, options RateLimitOptions[E]) Operator[E] {
+	if options.MaxWait <= 0 {
+		options.MaxWait = DefaultRateLimitMaxWait
+	}
+
+	return func(factory EventSetFactory) EventSetFactory {
+		return wrapFactory[E](factory, nil, func(set *deferredEventSet) {
+			if options.Policy == RateLimitWait {
+				delay, ok := limiter.Reserve(options.MaxWait)
+				switch {
+				case !ok:
+					limited(set, options)
+				case delay <= 0:
+					set.execute()
+				default:
+					time.AfterFunc(delay, set.execute)
+				}
+				return
+			}
+
+			if limiter.Allow() {
+				set.execute()
+				return
+			}
+			limited(set, options)
+		})
+	}
+}
+
+// limited
+//
+// Rejects the event exceeding the rate, or hands it to the DeadLetter function on the event process pool.
+func limited[E any](set *deferredEventSet, options RateLimitOptions[E]) {
+	if options.Policy == RateLimitReject || options.DeadLetter == nil {
+		set.reject(errors.RateLimitedErr)
+		return
+	}
+
+	set.resume(func() func() {
+		var result error = errors.RateLimitedErr
+		if err := options.DeadLetter(set.ctx, Envelope[E]{Metadata: set.metadata.clone(), Entity: set.entity.(E)}); err != nil {
+			result = stderrors.Join(result, err)
+		}
+		set.Context().SetResult(result)
+
+		return nil
+	})
+}
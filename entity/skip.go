@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type skipContextKey struct{}
+
+// SkipEvent
+//
+// Marks the event being processed with ctx (the context given to a ContextEventListener or an EnvelopeEventListener)
+// as skipped: Then and Catch of the listener are not called, and EventContext.IsSkipped reports true.
+// It returns false if ctx is not the context of an event listener execution.
+func SkipEvent(ctx context.Context) bool {
+	skipped, ok := ctx.Value(skipContextKey{}).(*atomic.Bool)
+	if !ok {
+		return false
+	}
+	skipped.Store(true)
+
+	return true
+}
+
+func contextWithSkip(ctx context.Context) (context.Context, *atomic.Bool) {
+	skipped := &atomic.Bool{}
+	return context.WithValue(ctx, skipContextKey{}, skipped), skipped
+}
@@ -14,6 +14,20 @@ const (
 	AlreadyRegistered ErrorID = iota
 	NotFoundEventListener
 	NoTriggerFunc
+	ApplicationNotRunning
+	ApplicationClosed
+	InvalidConfiguration
+	UnknownEventType
+	CorruptedLog
+	LogClosed
+	BufferFull
+	BridgeClosed
+	CircuitOpen
+	WrongExpectedVersion
+	StepTimeout
+	RateLimited
+	NoReply
+	InProgress
 )
 
 var (
@@ -29,4 +43,60 @@ var (
 		error:   errors.New("NoTriggerFunc"),
 		ErrorID: NoTriggerFunc,
 	}
+	ApplicationNotRunningErr = Error{
+		error:   errors.New("ApplicationNotRunning"),
+		ErrorID: ApplicationNotRunning,
+	}
+	ApplicationClosedErr = Error{
+		error:   errors.New("ApplicationClosed"),
+		ErrorID: ApplicationClosed,
+	}
+	InvalidConfigurationErr = Error{
+		error:   errors.New("InvalidConfiguration"),
+		ErrorID: InvalidConfiguration,
+	}
+	UnknownEventTypeErr = Error{
+		error:   errors.New("UnknownEventType"),
+		ErrorID: UnknownEventType,
+	}
+	CorruptedLogErr = Error{
+		error:   errors.New("CorruptedLog"),
+		ErrorID: CorruptedLog,
+	}
+	LogClosedErr = Error{
+		error:   errors.New("LogClosed"),
+		ErrorID: LogClosed,
+	}
+	BufferFullErr = Error{
+		error:   errors.New("BufferFull"),
+		ErrorID: BufferFull,
+	}
+	BridgeClosedErr = Error{
+		error:   errors.New("BridgeClosed"),
+		ErrorID: BridgeClosed,
+	}
+	CircuitOpenErr = Error{
+		error:   errors.New("CircuitOpen"),
+		ErrorID: CircuitOpen,
+	}
+	WrongExpectedVersionErr = Error{
+		error:   errors.New("WrongExpectedVersion"),
+		ErrorID: WrongExpectedVersion,
+	}
+	StepTimeoutErr = Error{
+		error:   errors.New("StepTimeout"),
+		ErrorID: StepTimeout,
+	}
+	RateLimitedErr = Error{
+		error:   errors.New("RateLimited"),
+		ErrorID: RateLimited,
+	}
+	NoReplyErr = Error{
+		error:   errors.New("NoReply"),
+		ErrorID: NoReply,
+	}
+	InProgressErr = Error{
+		error:   errors.New("InProgress"),
+		ErrorID: InProgress,
+	}
 )
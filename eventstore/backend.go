@@ -0,0 +1,166 @@
+package eventstore
+
+import (
+	"context"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"sync"
+	"time"
+)
+
+const (
+	// AnyVersion skips the optimistic concurrency check.
+	AnyVersion int64 = -1
+	// NoStream expects the stream not to exist.
+	NoStream int64 = 0
+)
+
+type Direction int
+
+const (
+	Forward Direction = iota
+	Backward
+)
+
+// EventData
+//
+// A serialized event to be appended.
+type EventData struct {
+	TypeName string
+	Payload  []byte
+	Metadata entity.Metadata
+}
+
+// RecordedEvent
+//
+// An event of a stream.
+// Version is its 1-based position in the stream, and Position its 1-based position among all the events of the store.
+type RecordedEvent struct {
+	StreamID   string          `json:"streamId"`
+	Version    int64           `json:"version"`
+	Position   int64           `json:"position"`
+	TypeName   string          `json:"type"`
+	Payload    []byte          `json:"payload"`
+	Metadata   entity.Metadata `json:"metadata"`
+	RecordedAt time.Time       `json:"recordedAt"`
+}
+
+// Backend
+//
+// The storage of the streams.
+//
+// Append writes the events atomically if the current version of the stream is expectedVersion
+// (or expectedVersion is AnyVersion), and returns errors.WrongExpectedVersionErr otherwise.
+// ReadStream reads up to limit (0 is unlimited) events of the stream from the version `from` (inclusive) in the direction;
+// reading backward from 0 starts at the last event.
+// ReadAll reads up to limit events from the global position `from` (inclusive).
+type Backend interface {
+	Append(ctx context.Context, streamID string, expectedVersion int64, events []EventData) ([]RecordedEvent, error)
+	ReadStream(ctx context.Context, streamID string, from int64, direction Direction, limit int) ([]RecordedEvent, error)
+	ReadAll(ctx context.Context, from int64, limit int) ([]RecordedEvent, error)
+}
+
+// MemoryBackend
+//
+// A Backend keeping the events in memory.
+type MemoryBackend struct {
+	lock sync.RWMutex
+	all  []RecordedEvent
+	// streams holds the global positions of the events of each stream.
+	streams map[string][]int64
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{streams: map[string][]int64{}}
+}
+
+func (b *MemoryBackend) Append(_ context.Context, streamID string, expectedVersion int64, events []EventData) ([]RecordedEvent, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	records, err := b.prepare(streamID, expectedVersion, events, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	b.commit(records)
+
+	return records, nil
+}
+
+func (b *MemoryBackend) ReadStream(_ context.Context, streamID string, from int64, direction Direction, limit int) ([]RecordedEvent, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	positions := b.streams[streamID]
+	var records []RecordedEvent
+	switch direction {
+	case Backward:
+		start := int64(len(positions))
+		if from > 0 && from < start {
+			start = from
+		}
+		for version := start; version >= 1 && (limit <= 0 || len(records) < limit); version-- {
+			records = append(records, b.all[positions[version-1]-1])
+		}
+	default:
+		for version := max(from, 1); version <= int64(len(positions)) && (limit <= 0 || len(records) < limit); version++ {
+			records = append(records, b.all[positions[version-1]-1])
+		}
+	}
+
+	return records, nil
+}
+
+func (b *MemoryBackend) ReadAll(_ context.Context, from int64, limit int) ([]RecordedEvent, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	start := max(from, 1) - 1
+	if start >= int64(len(b.all)) {
+		return nil, nil
+	}
+	end := int64(len(b.all))
+	if limit > 0 {
+		end = min(end, start+int64(limit))
+	}
+
+	return append([]RecordedEvent(nil), b.all[start:end]...), nil
+}
+
+// prepare
+//
+// Checks the expected version and numbers the events. (The lock must be held)
+func (b *MemoryBackend) prepare(streamID string, expectedVersion int64, events []EventData, now time.Time) ([]RecordedEvent, error) {
+	version := int64(len(b.streams[streamID]))
+	if expectedVersion != AnyVersion && expectedVersion != version {
+		return nil, errors.WrongExpectedVersionErr
+	}
+
+	position := int64(len(b.all))
+	records := make([]RecordedEvent, 0, len(events))
+	for _, event := range events {
+		version += 1
+		position += 1
+		records = append(records, RecordedEvent{
+			StreamID:   streamID,
+			Version:    version,
+			Position:   position,
+			TypeName:   event.TypeName,
+			Payload:    event.Payload,
+			Metadata:   event.Metadata,
+			RecordedAt: now,
+		})
+	}
+
+	return records, nil
+}
+
+// commit
+//
+// Adds the prepared events. (The lock must be held)
+func (b *MemoryBackend) commit(records []RecordedEvent) {
+	for _, record := range records {
+		b.all = append(b.all, record)
+		b.streams[record.StreamID] = append(b.streams[record.StreamID], record.Position)
+	}
+}
@@ -0,0 +1,150 @@
+package eventstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/internal/frame"
+	"io"
+	"os"
+	"time"
+)
+
+// FileBackend
+//
+// A Backend appending the events to a file and serving the reads from memory.
+//
+// Every Append is written as a single checksummed frame and flushed with fsync before it returns,
+// so the events of an Append are either all stored or, after an interrupted write, all discarded.
+//
+// If a write fails, the partial frame is cut off and the backend refuses the following appends until it is reopened.
+type FileBackend struct {
+	*MemoryBackend
+	file *os.File
+	// size is the size of the valid frames of the file.
+	size int64
+	// failed is the error of the failed write.
+	failed error
+}
+
+// OpenFileBackend
+//
+// Opens the file (it is created if it does not exist) and loads its events.
+// A torn frame at the end of the file (an interrupted write) is discarded.
+func OpenFileBackend(path string) (*FileBackend, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := NewMemoryBackend()
+	validSize, err := loadFrames(file, memory)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if err := file.Truncate(validSize); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &FileBackend{MemoryBackend: memory, file: file, size: validSize}, nil
+}
+
+func (b *FileBackend) Append(_ context.Context, streamID string, expectedVersion int64, events []EventData) ([]RecordedEvent, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.file == nil {
+		return nil, errors.LogClosedErr
+	}
+	if b.failed != nil {
+		return nil, b.failed
+	}
+
+	records, err := b.prepare(streamID, expectedVersion, events, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+	data, err := frame.Encode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := b.file.Write(data); err != nil {
+		return nil, b.fail(err)
+	}
+	if err := b.file.Sync(); err != nil {
+		return nil, b.fail(err)
+	}
+	b.size += int64(len(data))
+	b.commit(records)
+
+	return records, nil
+}
+
+// fail
+//
+// Cuts the partial frame off the file and refuses the following appends.
+func (b *FileBackend) fail(err error) error {
+	b.failed = stderrors.Join(errors.CorruptedLogErr, err)
+	if truncateErr := b.file.Truncate(b.size); truncateErr != nil {
+		return stderrors.Join(err, truncateErr)
+	}
+	if _, seekErr := b.file.Seek(b.size, io.SeekStart); seekErr != nil {
+		return stderrors.Join(err, seekErr)
+	}
+
+	return err
+}
+
+func (b *FileBackend) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file = nil
+
+	return err
+}
+
+// loadFrames
+//
+// Commits the events of the frames of the file to the memory and returns the size of the valid frames.
+func loadFrames(file *os.File, memory *MemoryBackend) (int64, error) {
+	reader := bufio.NewReader(file)
+	var validSize int64
+	for {
+		// a torn frame ends the valid frames like the end of the file
+		body, err := frame.Read(reader)
+		if err != nil {
+			return validSize, nil
+		}
+
+		var records []RecordedEvent
+		if err := json.Unmarshal(body, &records); err != nil {
+			return validSize, nil
+		}
+		for i, record := range records {
+			if record.Position != int64(len(memory.all)+i+1) {
+				return 0, errors.CorruptedLogErr
+			}
+		}
+		memory.commit(records)
+		validSize += int64(frame.HeaderSize + len(body))
+	}
+}
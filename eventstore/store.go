@@ -0,0 +1,130 @@
+package eventstore
+
+import (
+	"context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"time"
+)
+
+type Options struct {
+	// Publish publishes a committed event. It is eventx.TriggerAny when it is nil.
+	Publish func(ctx context.Context, event any, metadata entity.Metadata) ([]entity.EventContext, error)
+	// OnPublishError receives the errors of the publication. The events without a listener are not reported.
+	OnPublishError func(record RecordedEvent, err error)
+}
+
+// Store
+//
+// An event store of append-only streams.
+//
+// The events are serialized with the codec.Registry, and published to the bus listeners once the write has committed.
+type Store struct {
+	backend  Backend
+	registry *codec.Registry
+	options  Options
+}
+
+func New(backend Backend, registry *codec.Registry, options Options) (*Store, error) {
+	if backend == nil || registry == nil {
+		return nil, errors.InvalidConfigurationErr
+	}
+	if options.Publish == nil {
+		options.Publish = eventx.TriggerAny
+	}
+	if options.OnPublishError == nil {
+		options.OnPublishError = func(RecordedEvent, error) {}
+	}
+
+	return &Store{backend: backend, registry: registry, options: options}, nil
+}
+
+// Append
+//
+// Appends the events to the stream if its current version is expectedVersion (see AnyVersion and NoStream).
+// errors.WrongExpectedVersionErr is returned on a concurrent modification.
+//
+// If ctx carries the metadata of an event (the context given to a listener), the events are correlated with it.
+func (s *Store) Append(ctx context.Context, streamID string, expectedVersion int64, events ...any) ([]RecordedEvent, error) {
+	envelopes := make([]entity.Envelope[any], 0, len(events))
+	for _, event := range events {
+		envelopes = append(envelopes, entity.Envelope[any]{Entity: event})
+	}
+
+	return s.AppendEnvelopes(ctx, streamID, expectedVersion, envelopes...)
+}
+
+// AppendEnvelopes
+//
+// Works like Append with the metadata of the events (e.g. headers).
+func (s *Store) AppendEnvelopes(
+	ctx context.Context,
+	streamID string,
+	expectedVersion int64,
+	envelopes ...entity.Envelope[any],
+) ([]RecordedEvent, error) {
+	var parent *entity.Metadata
+	if parentMetadata, ok := entity.MetadataFromContext(ctx); ok {
+		parent = &parentMetadata
+	}
+
+	now := time.Now()
+	data := make([]EventData, 0, len(envelopes))
+	for _, envelope := range envelopes {
+		typeName, payload, err := s.registry.Encode(envelope.Entity)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata := envelope.Metadata.Complete(parent, now)
+		// filled by the bus when the event is published
+		metadata.EnqueuedAt = time.Time{}
+		data = append(data, EventData{TypeName: typeName, Payload: payload, Metadata: metadata})
+	}
+
+	records, err := s.backend.Append(ctx, streamID, expectedVersion, data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, record := range records {
+		_, err := s.options.Publish(ctx, envelopes[i].Entity, record.Metadata)
+		if err != nil && !stderrors.Is(err, errors.NotFoundEventListenerErr) {
+			s.options.OnPublishError(record, err)
+		}
+	}
+
+	return records, nil
+}
+
+// ReadStream
+//
+// Reads up to limit (0 is unlimited) events of the stream from the version `from` (inclusive) in the direction.
+// Reading backward from 0 starts at the last event.
+func (s *Store) ReadStream(ctx context.Context, streamID string, from int64, direction Direction, limit int) ([]RecordedEvent, error) {
+	return s.backend.ReadStream(ctx, streamID, from, direction, limit)
+}
+
+// ReadAll
+//
+// Reads up to limit (0 is unlimited) events of all the streams from the global position `from` (inclusive).
+func (s *Store) ReadAll(ctx context.Context, from int64, limit int) ([]RecordedEvent, error) {
+	return s.backend.ReadAll(ctx, from, limit)
+}
+
+// Registry
+//
+// Returns the codec.Registry the events are serialized with.
+func (s *Store) Registry() *codec.Registry {
+	return s.registry
+}
+
+// Decode
+//
+// Deserializes the recorded event with the codec.Registry of the store.
+func (s *Store) Decode(record RecordedEvent) (any, error) {
+	return s.registry.Decode(record.TypeName, record.Payload)
+}
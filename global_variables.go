@@ -2,6 +2,32 @@ package eventx
 
 import (
 	"github.com/aivyss/eventx/context"
+	"github.com/aivyss/eventx/entity"
+	"reflect"
+	"sync"
+	"time"
 )
 
 var appContext *context.ApplicationContext
+
+// appContextLock guards appContext, pendingEventListeners and the log settings.
+var appContextLock sync.RWMutex
+
+// pendingEventListeners
+//
+// Event listeners registered while no application is running.
+// They are registered to the next application context created by RunApplication.
+var pendingEventListeners []pendingEventListener
+
+type pendingEventListener struct {
+	typeVal  reflect.Type
+	listener any
+	factory  entity.EventSetFactory
+}
+
+// logger, slowListenerThreshold and tracer are applied to every application context created by RunApplication.
+var (
+	logger                context.Logger = context.NopLogger{}
+	slowListenerThreshold time.Duration
+	tracer                context.Tracer
+)
@@ -1,5 +1,5 @@
 module github.com/aivyss/eventx
 
-go 1.20
+go 1.21
 
-require github.com/aivyss/typex v1.0.0 // indirect
+require github.com/aivyss/typex v1.0.0
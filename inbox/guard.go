@@ -0,0 +1,109 @@
+package inbox
+
+import (
+	"context"
+	"github.com/aivyss/eventx/entity"
+)
+
+// KeyFunc
+//
+// Extracts the idempotency key of an event. An event with a blank key is processed without the guard.
+type KeyFunc[E any] func(envelope entity.Envelope[E]) string
+
+// EventIDKey
+//
+// Keys the events by the ID of their metadata. It is the default KeyFunc.
+func EventIDKey[E any](envelope entity.Envelope[E]) string {
+	return envelope.ID
+}
+
+// Guard
+//
+// Wraps the listener so that an event is processed once per key.
+//
+// The key is reserved in the store before the listener is called, committed if the listener succeeds
+// and released if it fails or panics, or if the commit fails. Duplicates of a committed key are skipped (see entity.SkipEvent): the listener, Then and Catch are not called.
+// A duplicate arriving while the key is only reserved fails with errors.InProgressErr, so it can be retried
+// and is not lost if the processing of the reserved key fails.
+// The keys are prefixed with the namespace, so several listeners of an event can share a store.
+func Guard[E any](namespace string, listener entity.EventListener[E], store Store, key KeyFunc[E]) entity.EventListener[E] {
+	if key == nil {
+		key = EventIDKey[E]
+	}
+
+	return &guardedEventListener[E]{
+		namespace: namespace,
+		listener:  listener,
+		store:     store,
+		key:       key,
+	}
+}
+
+type guardedEventListener[E any] struct {
+	namespace string
+	listener  entity.EventListener[E]
+	store     Store
+	key       KeyFunc[E]
+}
+
+func (l *guardedEventListener[E]) Trigger(e E) error {
+	return l.TriggerEnvelope(context.Background(), entity.Envelope[E]{Entity: e})
+}
+
+func (l *guardedEventListener[E]) TriggerEnvelope(ctx context.Context, envelope entity.Envelope[E]) (err error) {
+	key := l.key(envelope)
+	if key == "" {
+		return l.trigger(ctx, envelope)
+	}
+	key = l.namespace + "/" + key
+
+	reserved, err := l.store.Reserve(key)
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		entity.SkipEvent(ctx)
+		return nil
+	}
+
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			_ = l.store.Release(key)
+		}
+	}()
+
+	if err := l.trigger(ctx, envelope); err != nil {
+		return err
+	}
+	if err := l.store.Commit(key); err != nil {
+		// a key left reserved would reject every redelivery, so the event is processed again instead
+		return err
+	}
+	succeeded = true
+
+	return nil
+}
+
+func (l *guardedEventListener[E]) Then(e E) {
+	if listener, ok := l.listener.(entity.SuccessEventListener[E]); ok {
+		listener.Then(e)
+	}
+}
+
+func (l *guardedEventListener[E]) Catch(err error) {
+	if listener, ok := l.listener.(entity.CatchErrEventListener[E]); ok {
+		listener.Catch(err)
+	}
+}
+
+func (l *guardedEventListener[E]) trigger(ctx context.Context, envelope entity.Envelope[E]) error {
+	switch listener := l.listener.(type) {
+	case entity.EnvelopeEventListener[E]:
+		return listener.TriggerEnvelope(ctx, envelope)
+	case entity.ContextEventListener[E]:
+		return listener.TriggerContext(ctx, envelope.Entity)
+	default:
+		return listener.Trigger(envelope.Entity)
+	}
+}
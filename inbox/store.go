@@ -0,0 +1,280 @@
+package inbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/aivyss/eventx/errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store
+//
+// Records the keys of the processed events.
+//
+// Reserve claims a key atomically: it returns false if the key has been processed,
+// and errors.InProgressErr if the key is being processed, as its processing may still fail and need the redelivery.
+// A reserved key is either committed after the event has been processed or released if the processing has failed,
+// so that a redelivery can retry it.
+type Store interface {
+	Reserve(key string) (bool, error)
+	Commit(key string) error
+	Release(key string) error
+}
+
+// keySet
+//
+// The processed and reserved keys shared by MemoryStore and FileStore.
+type keySet struct {
+	ttl       time.Duration
+	processed map[string]time.Time
+	reserved  map[string]struct{}
+	sweptAt   time.Time
+}
+
+func newKeySet(ttl time.Duration) keySet {
+	return keySet{
+		ttl:       ttl,
+		processed: map[string]time.Time{},
+		reserved:  map[string]struct{}{},
+	}
+}
+
+func (s *keySet) expired(processedAt time.Time, now time.Time) bool {
+	return s.ttl > 0 && now.Sub(processedAt) >= s.ttl
+}
+
+func (s *keySet) reserve(key string, now time.Time) (bool, error) {
+	s.sweep(now)
+
+	if _, ok := s.reserved[key]; ok {
+		return false, errors.InProgressErr
+	}
+	if processedAt, ok := s.processed[key]; ok && !s.expired(processedAt, now) {
+		return false, nil
+	}
+	s.reserved[key] = struct{}{}
+
+	return true, nil
+}
+
+func (s *keySet) commit(key string, now time.Time) {
+	delete(s.reserved, key)
+	s.processed[key] = now
+}
+
+func (s *keySet) release(key string) {
+	delete(s.reserved, key)
+}
+
+// sweep
+//
+// Forgets the expired keys, at most once per TTL.
+func (s *keySet) sweep(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.sweptAt) < s.ttl {
+		return
+	}
+	s.sweptAt = now
+
+	for key, processedAt := range s.processed {
+		if s.expired(processedAt, now) {
+			delete(s.processed, key)
+		}
+	}
+}
+
+// MemoryStore
+//
+// A Store keeping the keys in memory. The processed keys are forgotten after the TTL; 0 keeps them forever.
+type MemoryStore struct {
+	lock sync.Mutex
+	keys keySet
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{keys: newKeySet(ttl)}
+}
+
+func (s *MemoryStore) Reserve(key string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.keys.reserve(key, time.Now())
+}
+
+func (s *MemoryStore) Commit(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.keys.commit(key, time.Now())
+	return nil
+}
+
+func (s *MemoryStore) Release(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.keys.release(key)
+	return nil
+}
+
+// FileStore
+//
+// A Store appending the processed keys to a file, so they survive a restart.
+// The reservations are kept in memory. The file is rewritten without the expired keys when it is opened.
+type FileStore struct {
+	lock sync.Mutex
+	keys keySet
+	file *os.File
+	size int64
+}
+
+type fileStoreLine struct {
+	Key         string    `json:"k"`
+	ProcessedAt time.Time `json:"t"`
+}
+
+// OpenFileStore
+//
+// Opens the file (it is created if it does not exist) and loads the processed keys.
+// The processed keys are forgotten after the TTL; 0 keeps them forever.
+func OpenFileStore(path string, ttl time.Duration) (*FileStore, error) {
+	keys := newKeySet(ttl)
+	now := time.Now()
+
+	if file, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line fileStoreLine
+			// a torn line of an interrupted write is ignored
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				continue
+			}
+			if !keys.expired(line.ProcessedAt, now) {
+				keys.processed[line.Key] = line.ProcessedAt
+			}
+		}
+		_ = file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := rewriteFileStore(path, keys.processed); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &FileStore{keys: keys, file: file, size: info.Size()}, nil
+}
+
+// rewriteFileStore
+//
+// Replaces the file with the given keys atomically.
+func rewriteFileStore(path string, processed map[string]time.Time) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	for key, processedAt := range processed {
+		if err := writeFileStoreLine(writer, key, processedAt); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func writeFileStoreLine(writer *bufio.Writer, key string, processedAt time.Time) error {
+	line, err := json.Marshal(fileStoreLine{Key: key, ProcessedAt: processedAt})
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(line); err != nil {
+		return err
+	}
+
+	return writer.WriteByte('\n')
+}
+
+func (s *FileStore) Reserve(key string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.keys.reserve(key, time.Now())
+}
+
+// Commit
+//
+// Records the key as processed and flushes it to the disk.
+// If the write fails, the file is truncated back to its last complete line, so the next line does not follow a torn one.
+func (s *FileStore) Commit(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	var line bytes.Buffer
+	writer := bufio.NewWriter(&line)
+	if err := writeFileStoreLine(writer, key, now); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := s.file.Write(line.Bytes()); err != nil {
+		_ = s.file.Truncate(s.size)
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		_ = s.file.Truncate(s.size)
+		return err
+	}
+	s.size += int64(line.Len())
+	s.keys.commit(key, now)
+
+	return nil
+}
+
+func (s *FileStore) Release(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.keys.release(key)
+	return nil
+}
+
+func (s *FileStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.file.Close()
+}
@@ -0,0 +1,267 @@
+package ingress
+
+import (
+	gocontext "context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/signature"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+const (
+	DefaultMaxBodySize = 1 << 20
+	DefaultWaitTimeout = 30 * time.Second
+)
+
+// Validator
+//
+// Implemented by the events validating themselves. The events failing the validation are rejected with 422.
+type Validator interface {
+	Validate() error
+}
+
+type Options struct {
+	// Secret enables the verification of the HMAC-SHA256 signature of the body (see the signature package).
+	// The requests without a valid signature are rejected with 401.
+	Secret []byte
+	// MaxBodySize is the limit of the body. It is DefaultMaxBodySize when it is 0.
+	MaxBodySize int64
+	// WaitTimeout bounds the waiting for the completion of the events. It is DefaultWaitTimeout when it is 0.
+	WaitTimeout time.Duration
+	// Validate validates the decoded events in addition to Validator.
+	Validate func(event any) error
+	// Trigger triggers a decoded event. It is eventx.TriggerAny when it is nil.
+	Trigger func(ctx gocontext.Context, event any, metadata entity.Metadata) ([]entity.EventContext, error)
+}
+
+// Request
+//
+// The body accepted by the Handler.
+// Type is the wire name of an event type registered to the codec.Registry (see codec.WireName), and Payload is its JSON.
+type Request struct {
+	Type          string            `json:"type"`
+	Payload       json.RawMessage   `json:"payload"`
+	ID            string            `json:"id,omitempty"`
+	CorrelationID string            `json:"correlationId,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+}
+
+// Response
+//
+// The body written by the Handler. Results is set when the client has waited for the completion.
+type Response struct {
+	EventIDs []string `json:"eventIds,omitempty"`
+	Results  []Result `json:"results,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Result
+//
+// The outcome of an event listener.
+type Result struct {
+	Error      string `json:"error,omitempty"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Superseded bool   `json:"superseded,omitempty"`
+}
+
+// Handler
+//
+// An http.Handler turning JSON requests into events.
+//
+// The event is triggered and its IDs are returned with 202.
+// With the "wait" query parameter ("wait=true" or a duration such as "wait=5s"), the handler waits for the listeners:
+// it responds 200 if they all succeeded, 500 if any failed and 504 if they have not finished in time.
+type Handler struct {
+	registry *codec.Registry
+	options  Options
+}
+
+func NewHandler(registry *codec.Registry, options Options) *Handler {
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = DefaultMaxBodySize
+	}
+	if options.WaitTimeout <= 0 {
+		options.WaitTimeout = DefaultWaitTimeout
+	}
+	if options.Trigger == nil {
+		options.Trigger = eventx.TriggerAny
+	}
+
+	return &Handler{registry: registry, options: options}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		respond(w, http.StatusMethodNotAllowed, Response{Error: "method not allowed"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.options.MaxBodySize))
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		respond(w, status, Response{Error: err.Error()})
+		return
+	}
+	if h.options.Secret != nil && !signature.Verify(h.options.Secret, body, r.Header.Get(signature.Header)) {
+		respond(w, http.StatusUnauthorized, Response{Error: "invalid signature"})
+		return
+	}
+
+	wait, timeout, err := h.waitTimeout(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, Response{Error: err.Error()})
+		return
+	}
+
+	var request Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		respond(w, http.StatusBadRequest, Response{Error: err.Error()})
+		return
+	}
+
+	event, status, err := h.decode(request)
+	if err != nil {
+		respond(w, status, Response{Error: err.Error()})
+		return
+	}
+
+	ctxs, err := h.options.Trigger(r.Context(), event, entity.Metadata{
+		ID:            request.ID,
+		CorrelationID: request.CorrelationID,
+		Headers:       request.Headers,
+	})
+	if err != nil {
+		respond(w, triggerErrorStatus(err), Response{Error: err.Error()})
+		return
+	}
+
+	response := Response{EventIDs: eventIDs(ctxs)}
+	if !wait {
+		respond(w, http.StatusAccepted, response)
+		return
+	}
+
+	waitContext, cancel := gocontext.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	for _, ctx := range ctxs {
+		select {
+		case <-ctx.Finished():
+		case <-waitContext.Done():
+			response.Error = "timeout"
+			respond(w, http.StatusGatewayTimeout, response)
+			return
+		}
+	}
+
+	status = http.StatusOK
+	for _, ctx := range ctxs {
+		result := Result{Skipped: ctx.IsSkipped(), Superseded: ctx.IsSuperseded()}
+		if err := ctx.Err(); err != nil {
+			result.Error = err.Error()
+			status = http.StatusInternalServerError
+		}
+		response.Results = append(response.Results, result)
+	}
+	respond(w, status, response)
+}
+
+// decode
+//
+// Upcasts the payload, decodes it into the registered type and validates it. The status of the failure is returned with the error.
+func (h *Handler) decode(request Request) (any, int, error) {
+	wireName, payload, err := h.registry.Upcast(request.Type, request.Payload)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	info, ok := h.registry.Resolve(wireName)
+	if !ok {
+		return nil, http.StatusBadRequest, errors.UnknownEventTypeErr
+	}
+
+	value := reflect.New(info.Type)
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, value.Interface()); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	event := value.Elem().Interface()
+
+	if validator, ok := value.Interface().(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+	}
+	if h.options.Validate != nil {
+		if err := h.options.Validate(event); err != nil {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+	}
+
+	return event, 0, nil
+}
+
+func (h *Handler) waitTimeout(r *http.Request) (bool, time.Duration, error) {
+	value := r.URL.Query().Get("wait")
+	switch value {
+	case "", "false":
+		return false, 0, nil
+	case "true":
+		return true, h.options.WaitTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return false, 0, err
+	}
+	if timeout <= 0 {
+		return false, 0, fmt.Errorf("wait must be positive: %s", value)
+	}
+
+	return true, min(timeout, h.options.WaitTimeout), nil
+}
+
+func triggerErrorStatus(err error) int {
+	switch {
+	case stderrors.Is(err, errors.NotFoundEventListenerErr):
+		return http.StatusNotFound
+	case stderrors.Is(err, errors.ApplicationNotRunningErr), stderrors.Is(err, errors.ApplicationClosedErr):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// eventIDs
+//
+// Returns the distinct IDs of the event contexts.
+func eventIDs(ctxs []entity.EventContext) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, ctx := range ctxs {
+		if id := ctx.EventID(); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func respond(w http.ResponseWriter, status int, response Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,64 @@
+// Package frame encodes the checksummed frames of the append-only files of eventx (the write-ahead log and the event store).
+package frame
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// HeaderSize is the size of the length and the CRC-32 of a frame.
+	HeaderSize = 8
+	// MaxSize bounds the body of a frame, so that a corrupted length is not allocated.
+	MaxSize = 64 << 20
+)
+
+// ErrTorn
+//
+// Returned by Read for an incomplete or corrupted frame, e.g. the end of an interrupted write.
+var ErrTorn = errors.New("frame: torn frame")
+
+// Encode
+//
+// Frames the body: [length uint32][crc32 uint32][body]
+func Encode(body []byte) ([]byte, error) {
+	if len(body) > MaxSize {
+		return nil, fmt.Errorf("frame: body of %d bytes exceeds %d bytes", len(body), MaxSize)
+	}
+
+	frame := make([]byte, HeaderSize+len(body))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(body))
+	copy(frame[HeaderSize:], body)
+
+	return frame, nil
+}
+
+// Read
+//
+// Reads the body of the next frame. It returns io.EOF at the end of the frames,
+// and ErrTorn if the frame is incomplete, longer than MaxSize or does not match its checksum.
+func Read(reader *bufio.Reader) ([]byte, error) {
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, ErrTorn
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size > MaxSize {
+		return nil, ErrTorn
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(reader, body); err != nil || crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, ErrTorn
+	}
+
+	return body, nil
+}
@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler
+//
+// Returns an http.Handler that serves the statistics of the current `eventx` application
+// in the Prometheus text exposition format.
+func Handler() http.Handler {
+	return NewHandler(eventx.Stats)
+}
+
+// NewHandler
+//
+// Returns an http.Handler that serves the statistics returned by stats
+// in the Prometheus text exposition format.
+func NewHandler(stats func() context.Stats) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+
+		writer := bufio.NewWriter(w)
+		Write(writer, stats())
+		_ = writer.Flush()
+	})
+}
+
+// Write
+//
+// Writes the statistics in the Prometheus text exposition format.
+// The metric names and labels are stable:
+//   - eventx_application_status{status}
+//   - eventx_queue_length{queue}, eventx_queue_capacity{queue}
+//   - eventx_workers{pool}, eventx_workers_running{pool}, eventx_workers_busy{pool}, eventx_worker_utilization{pool}
+//   - eventx_autoscale_decisions_total{direction}
+//   - eventx_events_triggered_total{event_type}, eventx_events_total{event_type,outcome}
+//   - eventx_listener_events_triggered_total{listener,event_type}, eventx_listener_events_total{listener,event_type,outcome}
+//   - eventx_queue_wait_seconds, eventx_execution_seconds (histograms)
+//   - eventx_listener_queue_wait_seconds{listener,event_type}, eventx_listener_execution_seconds{listener,event_type} (histograms)
+//
+// The outcome of eventx_events_total is one of succeeded, failed, canceled and dropped,
+// so that summing over the outcomes gives the number of finished event sets.
+func Write(w *bufio.Writer, stats context.Stats) {
+	header(w, "eventx_application_status", "gauge", "Lifecycle state of the application (1 for the current state).")
+	for _, status := range []context.ApplicationStatus{context.NotStarted, context.Running, context.Draining, context.Closed} {
+		value := 0
+		if stats.Status == status {
+			value = 1
+		}
+		sample(w, "eventx_application_status", labels("status", status.String()), strconv.Itoa(value))
+	}
+
+	queues := []struct {
+		name  string
+		depth context.QueueDepth
+	}{
+		{"dispense", stats.Queues.Dispense},
+		{"process", stats.Queues.Process},
+		{"after", stats.Queues.After},
+	}
+	header(w, "eventx_queue_length", "gauge", "Number of queued values.")
+	for _, queue := range queues {
+		sample(w, "eventx_queue_length", labels("queue", queue.name), strconv.Itoa(queue.depth.Len))
+	}
+	header(w, "eventx_queue_capacity", "gauge", "Buffer size of the queue.")
+	for _, queue := range queues {
+		sample(w, "eventx_queue_capacity", labels("queue", queue.name), strconv.Itoa(queue.depth.Cap))
+	}
+
+	pools := []struct {
+		name  string
+		stats context.PoolStats
+	}{
+		{"dispense", stats.Workers.Dispense},
+		{"process", stats.Workers.Process},
+		{"after", stats.Workers.After},
+	}
+	header(w, "eventx_workers", "gauge", "Target number of workers.")
+	for _, pool := range pools {
+		sample(w, "eventx_workers", labels("pool", pool.name), strconv.Itoa(pool.stats.Size))
+	}
+	header(w, "eventx_workers_running", "gauge", "Number of workers alive.")
+	for _, pool := range pools {
+		sample(w, "eventx_workers_running", labels("pool", pool.name), strconv.Itoa(pool.stats.Running))
+	}
+	header(w, "eventx_workers_busy", "gauge", "Number of workers handling a value.")
+	for _, pool := range pools {
+		sample(w, "eventx_workers_busy", labels("pool", pool.name), strconv.Itoa(pool.stats.Busy))
+	}
+	header(w, "eventx_worker_utilization", "gauge", "Ratio of busy workers to running workers.")
+	for _, pool := range pools {
+		utilization := 0.0
+		if pool.stats.Running > 0 {
+			utilization = float64(pool.stats.Busy) / float64(pool.stats.Running)
+		}
+		sample(w, "eventx_worker_utilization", labels("pool", pool.name), formatFloat(utilization))
+	}
+
+	header(w, "eventx_autoscale_decisions_total", "counter", "Number of scaling decisions of the adaptive mode.")
+	sample(w, "eventx_autoscale_decisions_total", labels("direction", "up"), strconv.Itoa(stats.Autoscale.ScaleUps))
+	sample(w, "eventx_autoscale_decisions_total", labels("direction", "down"), strconv.Itoa(stats.Autoscale.ScaleDowns))
+
+	eventTypes := make([]string, 0, len(stats.EventTypes))
+	for eventType := range stats.EventTypes {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+
+	header(w, "eventx_events_triggered_total", "counter", "Number of triggered event sets per event type.")
+	for _, eventType := range eventTypes {
+		sample(w, "eventx_events_triggered_total", labels("event_type", eventType), strconv.FormatUint(stats.EventTypes[eventType].Triggered, 10))
+	}
+
+	header(w, "eventx_events_total", "counter", "Number of finished event sets per event type and outcome.")
+	for _, eventType := range eventTypes {
+		counters(w, "eventx_events_total", []string{"event_type", eventType}, stats.EventTypes[eventType])
+	}
+
+	header(w, "eventx_listener_events_triggered_total", "counter", "Number of triggered event sets per event listener.")
+	for _, listener := range stats.Listeners {
+		sample(w, "eventx_listener_events_triggered_total", labels("listener", listener.ID, "event_type", listener.EventType), strconv.FormatUint(listener.Triggered, 10))
+	}
+
+	header(w, "eventx_listener_events_total", "counter", "Number of finished event sets per event listener and outcome.")
+	for _, listener := range stats.Listeners {
+		counters(w, "eventx_listener_events_total", []string{"listener", listener.ID, "event_type", listener.EventType}, listener.EventCounters)
+	}
+
+	header(w, "eventx_queue_wait_seconds", "histogram", "Time from Trigger to the start of the listener execution.")
+	histogram(w, "eventx_queue_wait_seconds", nil, stats.QueueWait)
+	header(w, "eventx_execution_seconds", "histogram", "Time spent in the listener execution.")
+	histogram(w, "eventx_execution_seconds", nil, stats.Execution)
+
+	header(w, "eventx_listener_queue_wait_seconds", "histogram", "Time from Trigger to the start of the listener execution per event listener.")
+	for _, listener := range stats.Listeners {
+		histogram(w, "eventx_listener_queue_wait_seconds", []string{"listener", listener.ID, "event_type", listener.EventType}, listener.QueueWait)
+	}
+	header(w, "eventx_listener_execution_seconds", "histogram", "Time spent in the listener execution per event listener.")
+	for _, listener := range stats.Listeners {
+		histogram(w, "eventx_listener_execution_seconds", []string{"listener", listener.ID, "event_type", listener.EventType}, listener.Execution)
+	}
+}
+
+func header(w *bufio.Writer, name string, metricType string, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func sample(w *bufio.Writer, name string, labels string, value string) {
+	_, _ = fmt.Fprintf(w, "%s%s %s\n", name, labels, value)
+}
+
+func counters(w *bufio.Writer, name string, pairs []string, c context.EventCounters) {
+	outcomes := []struct {
+		outcome string
+		value   uint64
+	}{
+		{"succeeded", c.Succeeded},
+		{"failed", c.Failed},
+		{"canceled", c.Canceled},
+		{"dropped", c.Dropped},
+	}
+
+	for _, outcome := range outcomes {
+		sample(w, name, labels(append(pairs, "outcome", outcome.outcome)...), strconv.FormatUint(outcome.value, 10))
+	}
+}
+
+// histogram
+//
+// Writes the histogram. The +Inf bucket and the count are summed from the bucket counts,
+// which are not read atomically with Histogram.Count, so that the series stay consistent.
+func histogram(w *bufio.Writer, name string, pairs []string, h context.Histogram) {
+	var cumulative uint64
+	for i, bound := range h.Buckets {
+		cumulative += h.Counts[i]
+		sample(w, name+"_bucket", labels(append(pairs, "le", formatFloat(bound.Seconds()))...), strconv.FormatUint(cumulative, 10))
+	}
+	for _, count := range h.Counts[len(h.Buckets):] {
+		cumulative += count
+	}
+	sample(w, name+"_bucket", labels(append(pairs, "le", "+Inf")...), strconv.FormatUint(cumulative, 10))
+	sample(w, name+"_sum", labels(pairs...), formatFloat(h.Sum.Seconds()))
+	sample(w, name+"_count", labels(pairs...), strconv.FormatUint(cumulative, 10))
+}
+
+// labels
+//
+// Formats label name/value pairs. ("name1", "value1", "name2", "value2" => {name1="value1",name2="value2"})
+func labels(pairs ...string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("{")
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(pairs[i])
+		builder.WriteString(`="`)
+		builder.WriteString(labelValueReplacer.Replace(pairs[i+1]))
+		builder.WriteString(`"`)
+	}
+	builder.WriteString("}")
+
+	return builder.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
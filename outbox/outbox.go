@@ -0,0 +1,183 @@
+package outbox
+
+import (
+	gocontext "context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"time"
+)
+
+const DefaultTable = "eventx_outbox"
+
+// Options
+//
+// The outbox table is expected to have the following columns:
+//
+//	id            VARCHAR(64) PRIMARY KEY
+//	seq           BIGINT NOT NULL UNIQUE, generated in the insertion order (e.g. BIGSERIAL, AUTO_INCREMENT)
+//	type_name     VARCHAR(255) NOT NULL
+//	payload       BLOB (BYTEA) NOT NULL
+//	metadata      TEXT NOT NULL
+//	created_at    TIMESTAMP NOT NULL
+//	dispatched_at TIMESTAMP NULL
+type Options struct {
+	// Table is the name of the outbox table. It is DefaultTable when it is blank.
+	Table string
+	// Placeholder returns the bind parameter of the n-th (1-based) argument. It is QuestionPlaceholder when it is nil.
+	Placeholder func(n int) string
+}
+
+// QuestionPlaceholder
+//
+// The bind parameters of MySQL and SQLite: ?
+func QuestionPlaceholder(int) string {
+	return "?"
+}
+
+// DollarPlaceholder
+//
+// The bind parameters of PostgreSQL: $1, $2, ...
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// Outbox
+//
+// A transactional outbox: the events are written to the outbox table in the transaction of the caller,
+// so they are published (by a Relay) only if the transaction commits.
+type Outbox struct {
+	db       *sql.DB
+	registry *codec.Registry
+	table    string
+	bind     func(n int) string
+}
+
+// Message
+//
+// A row of the outbox table.
+type Message struct {
+	ID       string
+	TypeName string
+	Payload  []byte
+	Metadata entity.Metadata
+}
+
+func New(db *sql.DB, registry *codec.Registry, options Options) (*Outbox, error) {
+	if db == nil || registry == nil {
+		return nil, errors.InvalidConfigurationErr
+	}
+	if options.Table == "" {
+		options.Table = DefaultTable
+	}
+	if options.Placeholder == nil {
+		options.Placeholder = QuestionPlaceholder
+	}
+
+	return &Outbox{
+		db:       db,
+		registry: registry,
+		table:    options.Table,
+		bind:     options.Placeholder,
+	}, nil
+}
+
+// Add
+//
+// Writes the event to the outbox table in the transaction and returns the ID of the event.
+// The type of the event must be registered to the codec.Registry of the Outbox.
+func (o *Outbox) Add(tx *sql.Tx, event any) (string, error) {
+	return o.AddEnvelope(tx, event, entity.Metadata{})
+}
+
+// AddEnvelope
+//
+// Works like Add with the metadata of the event (e.g. headers).
+// The ID and the occurrence time are filled if they are blank; the other blank fields are filled when the event is relayed.
+func (o *Outbox) AddEnvelope(tx *sql.Tx, event any, metadata entity.Metadata) (string, error) {
+	typeName, payload, err := o.registry.Encode(event)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if metadata.ID == "" {
+		metadata.ID = entity.NewEventID()
+	}
+	if metadata.OccurredAt.IsZero() {
+		metadata.OccurredAt = now
+	}
+	encodedMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (id, type_name, payload, metadata, created_at) VALUES (%s, %s, %s, %s, %s)",
+		o.table, o.bind(1), o.bind(2), o.bind(3), o.bind(4), o.bind(5),
+	)
+	if _, err := tx.Exec(query, metadata.ID, typeName, payload, string(encodedMetadata), now); err != nil {
+		return "", err
+	}
+
+	return metadata.ID, nil
+}
+
+// Pending
+//
+// Returns the oldest messages which have not been dispatched yet, in the order they have been added.
+func (o *Outbox) Pending(ctx gocontext.Context, limit int) ([]Message, error) {
+	query := fmt.Sprintf(
+		"SELECT id, type_name, payload, metadata FROM %s WHERE dispatched_at IS NULL ORDER BY seq LIMIT %d",
+		o.table, limit,
+	)
+	rows, err := o.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var message Message
+		var encodedMetadata string
+		if err := rows.Scan(&message.ID, &message.TypeName, &message.Payload, &encodedMetadata); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(encodedMetadata), &message.Metadata); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// MarkDispatched
+//
+// Records the dispatch of the message.
+func (o *Outbox) MarkDispatched(ctx gocontext.Context, id string) error {
+	query := fmt.Sprintf("UPDATE %s SET dispatched_at = %s WHERE id = %s", o.table, o.bind(1), o.bind(2))
+	_, err := o.db.ExecContext(ctx, query, time.Now(), id)
+
+	return err
+}
+
+// DeleteDispatched
+//
+// Deletes the messages dispatched before the given time and returns the number of the deleted rows.
+func (o *Outbox) DeleteDispatched(ctx gocontext.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(
+		"DELETE FROM %s WHERE dispatched_at IS NOT NULL AND dispatched_at < %s",
+		o.table, o.bind(1),
+	)
+	result, err := o.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,224 @@
+package outbox
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"fmt"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRelayInterval  = time.Second
+	DefaultRelayBatchSize = 100
+)
+
+type RelayOptions struct {
+	// Interval is the polling period. It is DefaultRelayInterval when it is 0.
+	Interval time.Duration
+	// BatchSize is the number of the messages relayed per poll. It is DefaultRelayBatchSize when it is 0.
+	BatchSize int
+	// Trigger publishes a decoded event. It is eventx.TriggerAny when it is nil.
+	Trigger func(ctx gocontext.Context, event any, metadata entity.Metadata) ([]entity.EventContext, error)
+	// OnError receives the errors of the polls and of the messages which could not be relayed.
+	OnError func(err error)
+	// DeadLetter receives the messages which cannot be decoded or have no event listener, before they are marked dispatched.
+	// If it fails, the message stays pending and the poll stops.
+	DeadLetter func(ctx gocontext.Context, message Message, err error) error
+}
+
+// Relay
+//
+// Polls the outbox table, triggers the messages into the bus and marks them dispatched.
+//
+// The delivery is at-least-once: a message is marked after all of its event listeners have succeeded,
+// so it is triggered again if the process stops in between or a listener fails.
+type Relay struct {
+	outbox  *Outbox
+	options RelayOptions
+	lock    sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func NewRelay(outbox *Outbox, options RelayOptions) *Relay {
+	if options.Interval <= 0 {
+		options.Interval = DefaultRelayInterval
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultRelayBatchSize
+	}
+	if options.Trigger == nil {
+		options.Trigger = eventx.TriggerAny
+	}
+	if options.OnError == nil {
+		options.OnError = func(error) {}
+	}
+
+	return &Relay{outbox: outbox, options: options}
+}
+
+// Start
+//
+// Starts polling in a goroutine. It does nothing if the relay is already running.
+func (r *Relay) Start() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go r.run(r.stop, r.done)
+}
+
+// Stop
+//
+// Stops polling and waits for the current poll.
+func (r *Relay) Stop() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+	r.stop = nil
+	r.done = nil
+}
+
+func (r *Relay) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		// a full batch means more messages may be pending
+		for {
+			relayed, err := r.RelayOnce(ctx)
+			if err != nil {
+				r.options.OnError(err)
+			}
+			if err != nil || relayed < r.options.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce
+//
+// Relays a batch of the pending messages and returns the number of the messages taken off the pending ones.
+//
+// The messages are triggered in order, and the trigger stops at the first message which cannot be triggered
+// (e.g. the application is not running) to keep the order.
+// Then the messages are marked dispatched once their event listeners have finished without an error;
+// the failed ones stay pending and their errors are returned.
+//
+// The messages which cannot be decoded or have no event listener are reported to OnError,
+// handed to DeadLetter (if any) and marked dispatched, so that they do not hold the relay back;
+// their rows are kept until DeleteDispatched.
+func (r *Relay) RelayOnce(ctx gocontext.Context) (int, error) {
+	messages, err := r.outbox.Pending(ctx, r.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var triggered []triggeredMessage
+	var triggerErr error
+	for _, message := range messages {
+		ctxs, err := r.trigger(ctx, message)
+		if err != nil {
+			triggerErr = err
+			break
+		}
+		triggered = append(triggered, triggeredMessage{message: message, ctxs: ctxs})
+	}
+
+	relayed := 0
+	var errs []error
+	for _, t := range triggered {
+		if err := waitSucceeded(ctx, t.ctxs); err != nil {
+			errs = append(errs, fmt.Errorf("outbox message %s: %w", t.message.ID, err))
+			continue
+		}
+		if err := r.outbox.MarkDispatched(ctx, t.message.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		relayed += 1
+	}
+
+	return relayed, stderrors.Join(append(errs, triggerErr)...)
+}
+
+type triggeredMessage struct {
+	message Message
+	ctxs    []entity.EventContext
+}
+
+// trigger
+//
+// Triggers the message. The messages which cannot be decoded or have no event listener are dead-lettered,
+// and no EventContext is returned for them.
+func (r *Relay) trigger(ctx gocontext.Context, message Message) ([]entity.EventContext, error) {
+	event, err := r.outbox.registry.Decode(message.TypeName, message.Payload)
+	if err == nil {
+		ctxs, triggerErr := r.options.Trigger(ctx, event, message.Metadata)
+		if !stderrors.Is(triggerErr, errors.NotFoundEventListenerErr) {
+			return ctxs, triggerErr
+		}
+		err = triggerErr
+	}
+
+	r.options.OnError(fmt.Errorf("outbox message %s: %w", message.ID, err))
+	if r.options.DeadLetter != nil {
+		if err := r.options.DeadLetter(ctx, message, err); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// waitSucceeded
+//
+// Waits for the event contexts and returns their errors.
+func waitSucceeded(ctx gocontext.Context, ctxs []entity.EventContext) error {
+	var errs []error
+	for _, eventContext := range ctxs {
+		select {
+		case <-eventContext.Finished():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := eventContext.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return stderrors.Join(errs...)
+}
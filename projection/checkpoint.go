@@ -0,0 +1,105 @@
+package projection
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CheckpointStore
+//
+// Stores the global position of the last event processed by each projection.
+// Load returns 0 for a projection without a checkpoint.
+type CheckpointStore interface {
+	Load(ctx context.Context, name string) (int64, error)
+	Save(ctx context.Context, name string, position int64) error
+}
+
+// MemoryCheckpointStore
+//
+// A CheckpointStore keeping the checkpoints in memory.
+type MemoryCheckpointStore struct {
+	lock        sync.RWMutex
+	checkpoints map[string]int64
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: map[string]int64{}}
+}
+
+func (s *MemoryCheckpointStore) Load(_ context.Context, name string) (int64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.checkpoints[name], nil
+}
+
+func (s *MemoryCheckpointStore) Save(_ context.Context, name string, position int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.checkpoints[name] = position
+	return nil
+}
+
+// FileCheckpointStore
+//
+// A CheckpointStore writing the checkpoint of each projection to a file of the directory.
+type FileCheckpointStore struct {
+	dir string
+}
+
+// NewFileCheckpointStore
+//
+// Creates the directory if it does not exist.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+func (s *FileCheckpointStore) Load(_ context.Context, name string) (int64, error) {
+	content, err := os.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+}
+
+// Save
+//
+// Replaces the checkpoint file atomically.
+func (s *FileCheckpointStore) Save(_ context.Context, name string, position int64) error {
+	tmp := s.path(name) + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(strconv.FormatInt(position, 10)); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path(name))
+}
+
+func (s *FileCheckpointStore) path(name string) string {
+	return filepath.Join(s.dir, url.PathEscape(name)+".checkpoint")
+}
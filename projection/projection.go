@@ -0,0 +1,47 @@
+package projection
+
+import (
+	"context"
+	"github.com/aivyss/eventx/eventstore"
+	"reflect"
+)
+
+// Projection
+//
+// Applies the events of the event store to a read model through typed handlers.
+// The events without a handler are skipped.
+type Projection struct {
+	name     string
+	handlers map[reflect.Type]func(ctx context.Context, event any, record eventstore.RecordedEvent) error
+	reset    func(ctx context.Context) error
+}
+
+// New
+//
+// Creates a projection. The name identifies its checkpoint.
+func New(name string) *Projection {
+	return &Projection{
+		name:     name,
+		handlers: map[reflect.Type]func(ctx context.Context, event any, record eventstore.RecordedEvent) error{},
+	}
+}
+
+func (p *Projection) Name() string {
+	return p.name
+}
+
+// Handle
+//
+// Registers the handler of the events of type E.
+func Handle[E any](p *Projection, handler func(ctx context.Context, event E, record eventstore.RecordedEvent) error) {
+	p.handlers[reflect.TypeOf((*E)(nil)).Elem()] = func(ctx context.Context, event any, record eventstore.RecordedEvent) error {
+		return handler(ctx, event.(E), record)
+	}
+}
+
+// OnReset
+//
+// Registers the function clearing the read model before a rebuild.
+func (p *Projection) OnReset(reset func(ctx context.Context) error) {
+	p.reset = reset
+}
@@ -0,0 +1,193 @@
+package projection
+
+import (
+	"context"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/eventstore"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBatchSize    = 100
+	DefaultPollInterval = 100 * time.Millisecond
+)
+
+type RunnerOptions struct {
+	// BatchSize is the number of the events read at once. The checkpoint is saved after every batch.
+	// It is DefaultBatchSize when it is 0.
+	BatchSize int
+	// PollInterval is the period the store is polled at while tailing. It is DefaultPollInterval when it is 0.
+	PollInterval time.Duration
+}
+
+// Runner
+//
+// Feeds a Projection with the global stream of an event store from its checkpoint.
+//
+// The checkpoint is saved after every batch, so after a crash the events of the last batch can be applied again:
+// the handlers should be idempotent.
+type Runner struct {
+	projection  *Projection
+	store       *eventstore.Store
+	checkpoints CheckpointStore
+	options     RunnerOptions
+	// lock serializes the catch-ups and the rebuilds.
+	lock     sync.Mutex
+	position int64
+	loaded   bool
+}
+
+func NewRunner(projection *Projection, store *eventstore.Store, checkpoints CheckpointStore, options RunnerOptions) (*Runner, error) {
+	if projection == nil || store == nil || checkpoints == nil {
+		return nil, errors.InvalidConfigurationErr
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+
+	return &Runner{
+		projection:  projection,
+		store:       store,
+		checkpoints: checkpoints,
+		options:     options,
+	}, nil
+}
+
+// Run
+//
+// Catches up from the checkpoint, then tails the store for the new events until ctx is done.
+// It stops and returns the error if a handler fails; the failed event is applied again by the next run.
+func (r *Runner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.CatchUp(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// CatchUp
+//
+// Applies the events after the checkpoint and returns the new position.
+func (r *Runner) CatchUp(ctx context.Context) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.loaded {
+		position, err := r.checkpoints.Load(ctx, r.projection.name)
+		if err != nil {
+			return 0, err
+		}
+		r.position = position
+		r.loaded = true
+	}
+
+	return r.catchUp(ctx)
+}
+
+// Rebuild
+//
+// Resets the checkpoint and the read model (see Projection.OnReset), and applies all the events again.
+//
+// The checkpoint is reset first: if the process stops in between, the events are applied again over the old read model,
+// instead of the later events only over an empty one.
+func (r *Runner) Rebuild(ctx context.Context) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.checkpoints.Save(ctx, r.projection.name, 0); err != nil {
+		return 0, err
+	}
+	r.position = 0
+	r.loaded = true
+
+	if r.projection.reset != nil {
+		if err := r.projection.reset(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.catchUp(ctx)
+}
+
+// Position
+//
+// Returns the global position of the last applied event.
+func (r *Runner) Position() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.position
+}
+
+func (r *Runner) catchUp(ctx context.Context) (int64, error) {
+	for ctx.Err() == nil {
+		records, err := r.store.ReadAll(ctx, r.position+1, r.options.BatchSize)
+		if err != nil {
+			return r.position, err
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		position := r.position
+		for _, record := range records {
+			if err := r.apply(ctx, record); err != nil {
+				_ = r.save(ctx, position)
+				return r.position, err
+			}
+			position = record.Position
+		}
+		if err := r.save(ctx, position); err != nil {
+			return r.position, err
+		}
+
+		if len(records) < r.options.BatchSize {
+			break
+		}
+	}
+
+	return r.position, nil
+}
+
+func (r *Runner) apply(ctx context.Context, record eventstore.RecordedEvent) error {
+	info, ok := r.store.Registry().DecodedType(record.TypeName)
+	if !ok {
+		return nil
+	}
+	handler, ok := r.projection.handlers[info.Type]
+	if !ok {
+		return nil
+	}
+
+	event, err := r.store.Decode(record)
+	if err != nil {
+		return err
+	}
+
+	return handler(ctx, event, record)
+}
+
+func (r *Runner) save(ctx context.Context, position int64) error {
+	if position == r.position {
+		return nil
+	}
+	if err := r.checkpoints.Save(ctx, r.projection.name, position); err != nil {
+		return err
+	}
+	r.position = position
+
+	return nil
+}
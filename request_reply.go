@@ -0,0 +1,280 @@
+package eventx
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx/context"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"reflect"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// Bus
+//
+// Publishes the events into an `eventx` application.
+type Bus interface {
+	Publish(ctx gocontext.Context, event any) error
+}
+
+type BusFunc func(ctx gocontext.Context, event any) error
+
+func (f BusFunc) Publish(ctx gocontext.Context, event any) error {
+	return f(ctx, event)
+}
+
+// DefaultBus
+//
+// Publishes the events into the running application. (See TriggerAny)
+var DefaultBus Bus = BusFunc(func(ctx gocontext.Context, event any) error {
+	_, err := TriggerAny(ctx, event, entity.Metadata{})
+	return err
+})
+
+// Responder
+//
+// Answers the queries of type Q with a reply of type R. (See Request)
+type Responder[Q any, R any] interface {
+	Respond(ctx gocontext.Context, query Q) (R, error)
+}
+
+type ResponderFunc[Q any, R any] func(ctx gocontext.Context, query Q) (R, error)
+
+func (f ResponderFunc[Q, R]) Respond(ctx gocontext.Context, query Q) (R, error) {
+	return f(ctx, query)
+}
+
+// RegisterResponder
+//
+// Registers the responder of the queries of type Q as an event listener, so the queries are answered by the event process pool.
+// Only one responder can be registered per query type: errors.AlreadyRegisteredErr is returned for the second one,
+// even in multi event mode.
+func RegisterResponder[Q any, R any](responder Responder[Q, R]) error {
+	if responder == nil {
+		return errors.NoTriggerFuncErr
+	}
+
+	var q Q
+	typeVal := reflect.TypeOf(q)
+	listener := &responderListener[Q, R]{responder: responder}
+
+	appContextLock.Lock()
+	defer appContextLock.Unlock()
+
+	factory := entity.NewEventSetFactory[Q](listener)
+	if bufferingEventListeners() {
+		for _, pending := range pendingEventListeners {
+			if _, ok := pending.listener.(replier); ok && pending.typeVal == typeVal {
+				return errors.AlreadyRegisteredErr
+			}
+		}
+
+		// buffered until the next RunApplication
+		pendingEventListeners = append(pendingEventListeners, pendingEventListener{
+			typeVal:  typeVal,
+			listener: listener,
+			factory:  factory,
+		})
+
+		return nil
+	}
+
+	if findResponder(appContext, typeVal) != nil {
+		return errors.AlreadyRegisteredErr
+	}
+	_, err := appContext.RegisterEventListenerIfAbsent(typeVal, listener, factory)
+	return err
+}
+
+// RegisterFuncAsResponder
+//
+// Works like RegisterResponder with a function.
+func RegisterFuncAsResponder[Q any, R any](respond func(ctx gocontext.Context, query Q) (R, error)) error {
+	if respond == nil {
+		return errors.NoTriggerFuncErr
+	}
+
+	return RegisterResponder[Q, R](ResponderFunc[Q, R](respond))
+}
+
+// Request
+//
+// Publishes the query through the bus (DefaultBus if it is nil) and waits for the reply of the responder of its type.
+//
+// errors.NotFoundEventListenerErr is returned if no responder of Q is registered in the running application,
+// and errors.InvalidConfigurationErr if its reply type is not R.
+// The cancellation and the deadline of ctx bound the wait, and cancel the context given to the responder.
+// The reply is passed within the process, so the bus must deliver the query with the values of ctx. (e.g. DefaultBus)
+//
+// If the bus triggers the query in the process (e.g. DefaultBus), the wait also ends when the event of the responder
+// finishes without a reply (e.g. it is canceled, superseded or rejected): errors.NoReplyErr is returned,
+// joined with the error of the event if any.
+func Request[Q any, R any](ctx gocontext.Context, bus Bus, query Q) (R, error) {
+	var zero R
+
+	app := currentApplicationContext()
+	if app == nil {
+		return zero, errors.ApplicationNotRunningErr
+	}
+	registered := findResponder(app, reflect.TypeOf(query))
+	if registered == nil {
+		return zero, errors.NotFoundEventListenerErr
+	}
+	if registered.replyType() != reflect.TypeOf((*R)(nil)).Elem() {
+		return zero, errors.InvalidConfigurationErr
+	}
+
+	if bus == nil {
+		bus = DefaultBus
+	}
+
+	slot := &replySlot{
+		request:   ctx,
+		queryType: reflect.TypeOf(query),
+		reply:     make(chan replyResult, 1),
+		queued:    make(chan entity.EventContext, 1),
+	}
+	if err := bus.Publish(gocontext.WithValue(ctx, replySlotKey{}, slot), query); err != nil {
+		return zero, err
+	}
+
+	var queued entity.EventContext
+	var finished <-chan struct{}
+	for {
+		select {
+		case result := <-slot.reply:
+			return replied[R](result)
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case queued = <-slot.queued:
+			finished = queued.Finished()
+		case <-finished:
+			// the reply is sent before the event finishes
+			select {
+			case result := <-slot.reply:
+				return replied[R](result)
+			default:
+			}
+			if err := queued.Err(); err != nil {
+				return zero, stderrors.Join(errors.NoReplyErr, err)
+			}
+			return zero, errors.NoReplyErr
+		}
+	}
+}
+
+func replied[R any](result replyResult) (R, error) {
+	if result.err != nil {
+		var zero R
+		return zero, result.err
+	}
+
+	return result.value.(R), nil
+}
+
+type replySlotKey struct{}
+
+type replyResult struct {
+	value any
+	err   error
+}
+
+// replySlot
+//
+// Carries the reply of a Request from the responder, through the context of the query.
+// It is claimed by the first responder of the query type receiving it,
+// so the queries of the same type published by the responder itself do not answer it.
+type replySlot struct {
+	request   gocontext.Context
+	queryType reflect.Type
+	claimed   atomic.Bool
+	reply     chan replyResult
+	// queued receives the EventContext of the responder, which is watched once.
+	watched atomic.Bool
+	queued  chan entity.EventContext
+}
+
+// watch
+//
+// Hands the EventContext of the responder of the triggered query over to the Request.
+func (s *replySlot) watch(registrations []*context.ListenerRegistration, ctxs []entity.EventContext) {
+	for i, registration := range registrations {
+		if _, ok := registration.Listener.(replier); !ok || registration.EventType != s.queryType || i >= len(ctxs) {
+			continue
+		}
+		if s.watched.CompareAndSwap(false, true) {
+			s.queued <- ctxs[i]
+		}
+		return
+	}
+}
+
+// replier
+//
+// Marks the event listeners registered by RegisterResponder.
+type replier interface {
+	replyType() reflect.Type
+}
+
+type responderListener[Q any, R any] struct {
+	responder Responder[Q, R]
+}
+
+func (l *responderListener[Q, R]) replyType() reflect.Type {
+	return reflect.TypeOf((*R)(nil)).Elem()
+}
+
+func (l *responderListener[Q, R]) Trigger(query Q) error {
+	return l.TriggerContext(gocontext.Background(), query)
+}
+
+// TriggerContext
+//
+// Answers the query. A query published without Request is answered and the reply is discarded.
+func (l *responderListener[Q, R]) TriggerContext(ctx gocontext.Context, query Q) error {
+	slot, ok := ctx.Value(replySlotKey{}).(*replySlot)
+	if !ok || slot.queryType != reflect.TypeOf(query) || !slot.claimed.CompareAndSwap(false, true) {
+		_, err := l.respond(ctx, query)
+		return err
+	}
+
+	if err := slot.request.Err(); err != nil {
+		// the requester has given up before the query has been processed
+		entity.SkipEvent(ctx)
+		return nil
+	}
+
+	ctx, cancel := gocontext.WithCancel(ctx)
+	defer cancel()
+	stop := gocontext.AfterFunc(slot.request, cancel)
+	defer stop()
+
+	reply, err := l.respond(ctx, query)
+	slot.reply <- replyResult{value: reply, err: err}
+
+	return err
+}
+
+// respond
+//
+// Calls the responder. A panic of the responder is returned as *entity.PanicError.
+func (l *responderListener[Q, R]) respond(ctx gocontext.Context, query Q) (reply R, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &entity.PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return l.responder.Respond(ctx, query)
+}
+
+func findResponder(app *context.ApplicationContext, typeVal reflect.Type) replier {
+	for _, registration := range app.GetEventListenerRegistrations(typeVal) {
+		if r, ok := registration.Listener.(replier); ok {
+			return r
+		}
+	}
+
+	return nil
+}
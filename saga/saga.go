@@ -0,0 +1,429 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const DefaultInterval = time.Second
+
+type Options struct {
+	// Store persists the instances. It is a MemoryStore when it is nil.
+	Store Store
+	// Interval is the period of the timeout checks. It is DefaultInterval when it is 0.
+	Interval time.Duration
+	// OnError receives the errors of the timeout checks.
+	OnError func(err error)
+}
+
+// Step
+//
+// A step of a saga of state S, triggered by the events of type E.
+//
+// Action runs when the triggering event arrives and the previous steps have completed.
+// Compensate undoes the action when a later step fails or times out. It may be nil.
+// Timeout bounds the time from the completion of the previous step (or the arrival of the triggering event
+// for the first step) to the completion of the action. 0 means no timeout.
+type Step[E any, S any] struct {
+	Name       string
+	Action     func(ctx context.Context, event E, state *S) error
+	Compensate func(ctx context.Context, state *S) error
+	Timeout    time.Duration
+}
+
+type step[S any] struct {
+	name       string
+	typeVal    reflect.Type
+	action     func(ctx context.Context, event any, state *S) error
+	compensate func(ctx context.Context, state *S) error
+	timeout    time.Duration
+	register   func(handle func(ctx context.Context, event any) error) error
+}
+
+// Saga
+//
+// Orchestrates a multi-step workflow of state S through the events of the bus.
+//
+// An instance is started by the triggering event of the first step, and the following events are associated to it
+// by their correlation ID (See entity.Metadata), so the actions should trigger the next events with the context they receive.
+// The events which are not expected by the instance of their correlation ID are skipped. (See entity.SkipEvent)
+//
+// When an action fails or a step times out, the compensations of the completed steps run in the reverse order.
+// The state is persisted as JSON in the Store after every step.
+type Saga[S any] struct {
+	name    string
+	options Options
+	steps   []step[S]
+	keys    keyLocks
+	lock    sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// New
+//
+// Creates a saga. The name identifies its instances in the Store.
+func New[S any](name string, options Options) *Saga[S] {
+	if options.Store == nil {
+		options.Store = NewMemoryStore()
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	if options.OnError == nil {
+		options.OnError = func(error) {}
+	}
+
+	return &Saga[S]{
+		name:    name,
+		options: options,
+		keys:    keyLocks{entries: map[string]*keyLock{}},
+	}
+}
+
+func (s *Saga[S]) Name() string {
+	return s.name
+}
+
+// AddStep
+//
+// Appends the step to the saga. The steps run in the order they are added.
+func AddStep[E any, S any](s *Saga[S], st Step[E, S]) {
+	added := step[S]{
+		name:       st.Name,
+		typeVal:    reflect.TypeOf((*E)(nil)).Elem(),
+		compensate: st.Compensate,
+		timeout:    st.Timeout,
+		register: func(handle func(ctx context.Context, event any) error) error {
+			return eventx.RegisterFuncContextAsEventListener(func(ctx context.Context, e E) error {
+				return handle(ctx, e)
+			})
+		},
+	}
+	if st.Action != nil {
+		added.action = func(ctx context.Context, event any, state *S) error {
+			return st.Action(ctx, event.(E), state)
+		}
+	}
+
+	s.steps = append(s.steps, added)
+}
+
+// Register
+//
+// Registers the event listeners of the steps into `eventx`.
+// errors.InvalidConfigurationErr is returned if the saga has no step, a step has no action,
+// or two steps are triggered by the same event type.
+func (s *Saga[S]) Register() error {
+	if len(s.steps) == 0 {
+		return errors.InvalidConfigurationErr
+	}
+
+	types := map[reflect.Type]struct{}{}
+	for _, st := range s.steps {
+		if st.action == nil {
+			return errors.InvalidConfigurationErr
+		}
+		if _, ok := types[st.typeVal]; ok {
+			return errors.InvalidConfigurationErr
+		}
+		types[st.typeVal] = struct{}{}
+	}
+
+	for i := range s.steps {
+		index := i
+		if err := s.steps[index].register(func(ctx context.Context, event any) error {
+			return s.handle(ctx, index, event)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Instance
+//
+// Returns the instance of the correlation ID.
+func (s *Saga[S]) Instance(ctx context.Context, correlationID string) (Instance, bool, error) {
+	return s.options.Store.Load(ctx, s.name, correlationID)
+}
+
+// State
+//
+// Returns the decoded state of the instance.
+func (s *Saga[S]) State(instance Instance) (S, error) {
+	state, err := decodeState[S](instance)
+	if err != nil {
+		var zero S
+		return zero, err
+	}
+
+	return *state, nil
+}
+
+// handle
+//
+// Runs the step of the index for the event if the instance of its correlation ID expects it.
+func (s *Saga[S]) handle(ctx context.Context, index int, event any) error {
+	metadata, ok := entity.MetadataFromContext(ctx)
+	if !ok || metadata.CorrelationID == "" {
+		entity.SkipEvent(ctx)
+		return nil
+	}
+
+	unlock := s.keys.acquire(metadata.CorrelationID)
+	defer unlock()
+
+	now := time.Now()
+	instance, found, err := s.options.Store.Load(ctx, s.name, metadata.CorrelationID)
+	if err != nil {
+		return err
+	}
+	if index == 0 {
+		if found {
+			// a redelivered starting event
+			entity.SkipEvent(ctx)
+			return nil
+		}
+
+		instance = Instance{
+			Saga:          s.name,
+			CorrelationID: metadata.CorrelationID,
+			Status:        Running,
+			StartedAt:     now,
+		}
+		if timeout := s.steps[0].timeout; timeout > 0 {
+			instance.Deadline = now.Add(timeout)
+		}
+	} else if !found || instance.Status != Running || instance.Step != index {
+		entity.SkipEvent(ctx)
+		return nil
+	}
+
+	state, err := decodeState[S](instance)
+	if err != nil {
+		return err
+	}
+
+	actionContext, cancel := ctx, context.CancelFunc(func() {})
+	if !instance.Deadline.IsZero() {
+		actionContext, cancel = context.WithDeadline(ctx, instance.Deadline)
+	}
+	err = s.steps[index].action(actionContext, event, state)
+	cancel()
+	if err != nil {
+		return stderrors.Join(err, s.compensate(ctx, instance, state, err))
+	}
+
+	instance.Step = index + 1
+	instance.Deadline = time.Time{}
+	if instance.Step == len(s.steps) {
+		instance.Status = Completed
+	} else if timeout := s.steps[instance.Step].timeout; timeout > 0 {
+		instance.Deadline = time.Now().Add(timeout)
+	}
+
+	return s.save(ctx, instance, state)
+}
+
+// compensate
+//
+// Runs the compensations of the completed steps in the reverse order and records the cause.
+// The errors of the compensations are returned.
+func (s *Saga[S]) compensate(ctx context.Context, instance Instance, state *S, cause error) error {
+	var errs []error
+	for i := instance.Step - 1; i >= 0; i-- {
+		compensate := s.steps[i].compensate
+		if compensate == nil {
+			continue
+		}
+		if err := compensate(ctx, state); err != nil {
+			errs = append(errs, fmt.Errorf("compensation of %s: %w", s.steps[i].name, err))
+		}
+	}
+
+	instance.Status = Compensated
+	if len(errs) > 0 {
+		instance.Status = CompensationFailed
+	}
+	instance.Error = cause.Error()
+	instance.Deadline = time.Time{}
+	if err := s.save(ctx, instance, state); err != nil {
+		errs = append(errs, err)
+	}
+
+	return stderrors.Join(errs...)
+}
+
+func (s *Saga[S]) save(ctx context.Context, instance Instance, state *S) error {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	instance.State = encoded
+	instance.UpdatedAt = time.Now()
+
+	return s.options.Store.Save(ctx, instance)
+}
+
+// CheckTimeouts
+//
+// Compensates the running instances whose deadline has passed, and returns their number.
+// The cause recorded in the instances wraps errors.StepTimeoutErr.
+func (s *Saga[S]) CheckTimeouts(ctx context.Context) (int, error) {
+	running, err := s.options.Store.Running(ctx, s.name)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	timedOut := 0
+	for _, candidate := range running {
+		if candidate.Deadline.IsZero() || time.Now().Before(candidate.Deadline) {
+			continue
+		}
+
+		compensated, err := s.timeout(ctx, candidate.CorrelationID)
+		if compensated {
+			timedOut += 1
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return timedOut, stderrors.Join(errs...)
+}
+
+// timeout
+//
+// Compensates the instance if it is still running past its deadline once its lock is held.
+func (s *Saga[S]) timeout(ctx context.Context, correlationID string) (bool, error) {
+	unlock := s.keys.acquire(correlationID)
+	defer unlock()
+
+	instance, found, err := s.options.Store.Load(ctx, s.name, correlationID)
+	if err != nil {
+		return false, err
+	}
+	if !found || instance.Status != Running || instance.Deadline.IsZero() || time.Now().Before(instance.Deadline) {
+		return false, nil
+	}
+
+	state, err := decodeState[S](instance)
+	if err != nil {
+		return false, err
+	}
+
+	cause := fmt.Errorf("%w: %s", errors.StepTimeoutErr, s.steps[instance.Step].name)
+	// the timeout itself is recorded in the instance, only the failures of the compensations are reported
+	return true, s.compensate(ctx, instance, state, cause)
+}
+
+// Start
+//
+// Starts checking the timeouts in a goroutine. It does nothing if the saga is already checking them.
+func (s *Saga[S]) Start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go s.run(s.stop, s.done)
+}
+
+// Stop
+//
+// Stops checking the timeouts and waits for the current check.
+func (s *Saga[S]) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+	s.done = nil
+}
+
+func (s *Saga[S]) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := s.CheckTimeouts(context.Background()); err != nil {
+				s.options.OnError(err)
+			}
+		}
+	}
+}
+
+func decodeState[S any](instance Instance) (*S, error) {
+	state := new(S)
+	if len(instance.State) == 0 {
+		return state, nil
+	}
+	if err := json.Unmarshal(instance.State, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// keyLocks
+//
+// Serializes the processing of the instances per correlation ID.
+type keyLocks struct {
+	lock    sync.Mutex
+	entries map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (l *keyLocks) acquire(key string) func() {
+	l.lock.Lock()
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &keyLock{}
+		l.entries[key] = entry
+	}
+	entry.refs += 1
+	l.lock.Unlock()
+
+	entry.Lock()
+
+	return func() {
+		entry.Unlock()
+
+		l.lock.Lock()
+		entry.refs -= 1
+		if entry.refs == 0 {
+			delete(l.entries, key)
+		}
+		l.lock.Unlock()
+	}
+}
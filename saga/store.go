@@ -0,0 +1,253 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status
+//
+// The lifecycle state of a saga instance.
+//
+// Running -> Completed | Compensated | CompensationFailed
+type Status int
+
+const (
+	// Running
+	//
+	// The instance is waiting for the triggering event of its next step.
+	Running Status = iota
+	// Completed
+	//
+	// Every step has completed.
+	Completed
+	// Compensated
+	//
+	// A step has failed or timed out, and the compensations of the completed steps have run.
+	Compensated
+	// CompensationFailed
+	//
+	// A step has failed or timed out, and at least one of the compensations has returned an error.
+	CompensationFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case Running:
+		return "Running"
+	case Completed:
+		return "Completed"
+	case Compensated:
+		return "Compensated"
+	case CompensationFailed:
+		return "CompensationFailed"
+	default:
+		return "Unknown"
+	}
+}
+
+// Instance
+//
+// The persisted state of a saga for a correlation ID.
+type Instance struct {
+	Saga          string
+	CorrelationID string
+	// Step is the number of the completed steps, i.e. the index of the next step.
+	Step   int
+	Status Status
+	// State is the JSON encoded state of the saga.
+	State json.RawMessage
+	// Deadline is the time by which the next step must complete. It is zero if the step has no timeout.
+	Deadline time.Time
+	// Error is the error of the failed or timed out step.
+	Error     string
+	StartedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Store
+//
+// Persists the saga instances per correlation ID.
+// Load returns false for a correlation ID without an instance.
+type Store interface {
+	Load(ctx context.Context, saga string, correlationID string) (Instance, bool, error)
+	Save(ctx context.Context, instance Instance) error
+	// Running returns the instances of the saga whose status is Running.
+	Running(ctx context.Context, saga string) ([]Instance, error)
+}
+
+// MemoryStore
+//
+// A Store keeping the instances in memory.
+type MemoryStore struct {
+	lock      sync.RWMutex
+	instances map[string]map[string]Instance
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{instances: map[string]map[string]Instance{}}
+}
+
+func (s *MemoryStore) Load(_ context.Context, saga string, correlationID string) (Instance, bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	instance, ok := s.instances[saga][correlationID]
+	if !ok {
+		return Instance{}, false, nil
+	}
+
+	return cloneInstance(instance), true, nil
+}
+
+func (s *MemoryStore) Save(_ context.Context, instance Instance) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	instances, ok := s.instances[instance.Saga]
+	if !ok {
+		instances = map[string]Instance{}
+		s.instances[instance.Saga] = instances
+	}
+	instances[instance.CorrelationID] = cloneInstance(instance)
+
+	return nil
+}
+
+func (s *MemoryStore) Running(_ context.Context, saga string) ([]Instance, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var running []Instance
+	for _, instance := range s.instances[saga] {
+		if instance.Status == Running {
+			running = append(running, cloneInstance(instance))
+		}
+	}
+
+	return running, nil
+}
+
+func cloneInstance(instance Instance) Instance {
+	instance.State = append(json.RawMessage(nil), instance.State...)
+	return instance
+}
+
+// FileStore
+//
+// A Store writing each instance to a JSON file of the directory of its saga.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore
+//
+// Creates the directory if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) Load(_ context.Context, saga string, correlationID string) (Instance, bool, error) {
+	instance, err := readInstance(s.path(saga, correlationID))
+	if os.IsNotExist(err) {
+		return Instance{}, false, nil
+	}
+	if err != nil {
+		return Instance{}, false, err
+	}
+
+	return instance, true, nil
+}
+
+// Save
+//
+// Replaces the file of the instance atomically.
+func (s *FileStore) Save(_ context.Context, instance Instance) error {
+	if err := os.MkdirAll(s.sagaDir(instance.Saga), 0o755); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(instance)
+	if err != nil {
+		return err
+	}
+
+	path := s.path(instance.Saga, instance.CorrelationID)
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (s *FileStore) Running(_ context.Context, saga string) ([]Instance, error) {
+	entries, err := os.ReadDir(s.sagaDir(saga))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var running []Instance
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		instance, err := readInstance(filepath.Join(s.sagaDir(saga), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if instance.Status == Running {
+			running = append(running, instance)
+		}
+	}
+
+	return running, nil
+}
+
+func (s *FileStore) sagaDir(saga string) string {
+	return filepath.Join(s.dir, url.PathEscape(saga))
+}
+
+func (s *FileStore) path(saga string, correlationID string) string {
+	return filepath.Join(s.sagaDir(saga), url.PathEscape(correlationID)+".json")
+}
+
+func readInstance(path string) (Instance, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Instance{}, err
+	}
+
+	var instance Instance
+	if err := json.Unmarshal(content, &instance); err != nil {
+		return Instance{}, err
+	}
+
+	return instance, nil
+}
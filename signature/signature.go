@@ -0,0 +1,43 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// Header is the HTTP header carrying the signature of the body.
+	Header = "X-Eventx-Signature"
+	prefix = "sha256="
+)
+
+// Sign
+//
+// Returns the HMAC-SHA256 signature of the body: "sha256=<hex>"
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify
+//
+// Reports whether the signature (as returned by Sign) matches the body. The comparison takes constant time.
+func Verify(secret []byte, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, prefix) {
+		return false
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, prefix))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/aggregate"
+	"testing"
+	"time"
+)
+
+type TestAccountOpened struct {
+	Owner string
+}
+
+type TestAccountDeposited struct {
+	Amount int
+}
+
+type TestAccount struct {
+	aggregate.AggregateRoot
+	Owner   string
+	Balance int
+}
+
+func NewTestAccount() *TestAccount {
+	account := &TestAccount{}
+	aggregate.On(&account.AggregateRoot, func(e TestAccountOpened) { account.Owner = e.Owner })
+	aggregate.On(&account.AggregateRoot, func(e TestAccountDeposited) { account.Balance += e.Amount })
+
+	return account
+}
+
+func TestAggregateRoot(t *testing.T) {
+	account := NewTestAccount()
+	account.Rehydrate(TestAccountOpened{Owner: "a"}, TestAccountDeposited{Amount: 1})
+	if account.Owner != "a" || account.Balance != 1 || account.Version() != 2 || len(account.Pending()) != 0 {
+		t.Fatalf("unexpected account: %+v", account)
+	}
+
+	account.Raise(TestAccountDeposited{Amount: 2})
+	account.Raise(TestAccountDeposited{Amount: 3})
+	if account.Balance != 6 || account.Version() != 2 || len(account.Pending()) != 2 {
+		t.Fatalf("unexpected account: %+v", account)
+	}
+	account.MarkSaved()
+	account.MarkSaved()
+	if account.Version() != 4 || len(account.Pending()) != 2 {
+		t.Fatalf("unexpected account: %+v", account)
+	}
+
+	// a failed publication keeps the unpublished events pending
+	var published []any
+	failing := aggregate.BusFunc(func(_ gocontext.Context, event any) error {
+		if len(published) == 1 {
+			return stderrors.New("failed")
+		}
+		published = append(published, event)
+		return nil
+	})
+	if err := account.PublishPending(gocontext.Background(), failing); err == nil {
+		t.Fatal("the publication must fail")
+	}
+	if len(published) != 1 || len(account.Pending()) != 1 || account.Version() != 4 {
+		t.Fatalf("published: %v, pending: %v, version: %d", published, account.Pending(), account.Version())
+	}
+
+	// the remaining event has been saved already
+	account.Raise(TestAccountDeposited{Amount: 4})
+	account.MarkSaved()
+	if account.Version() != 5 {
+		t.Fatalf("version: %d", account.Version())
+	}
+	account.ClearPending()
+	if account.Version() != 5 || len(account.Pending()) != 0 {
+		t.Fatalf("unexpected account: %+v", account)
+	}
+}
+
+func TestAggregateRootPublish(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	received := make(chan TestAccountDeposited, 2)
+	_ = eventx.RegisterFuncAsEventListener(func(e TestAccountDeposited) error {
+		received <- e
+		return nil
+	})
+
+	account := NewTestAccount()
+	account.Raise(TestAccountOpened{Owner: "a"})
+	account.Raise(TestAccountDeposited{Amount: 1})
+	account.MarkSaved()
+	// TestAccountOpened has no listener
+	if err := account.PublishPending(gocontext.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(account.Pending()) != 0 || account.Version() != 2 {
+		t.Fatalf("unexpected account: %+v", account)
+	}
+
+	select {
+	case e := <-received:
+		if e.Amount != 1 {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event was not published")
+	}
+}
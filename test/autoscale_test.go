@@ -0,0 +1,115 @@
+package test
+
+import (
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/context"
+	"testing"
+	"time"
+)
+
+type TestAutoscaleEntity int
+
+func TestAutoscale(t *testing.T) {
+	t.Run("grows under load and shrinks when idle", func(t *testing.T) {
+		eventx.RunApplication(5, 1, true)
+		defer eventx.Close()
+
+		decisions := make(chan context.ScalingDecision, 100)
+		_ = eventx.RegisterFuncAsEventListener(func(decision context.ScalingDecision) error {
+			decisions <- decision
+			return nil
+		})
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestAutoscaleEntity) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})
+
+		err := eventx.EnableAutoscale(context.AutoscaleConfig{
+			MinProcessPoolSize: 2,
+			MaxProcessPoolSize: 6,
+			TargetQueueDepth:   2,
+			IdleTimeout:        100 * time.Millisecond,
+			Interval:           10 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 100; i++ {
+			_, _ = eventx.Trigger(TestAutoscaleEntity(i))
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			stats := eventx.AutoscaleStats()
+			if stats.ScaleUps > 0 && stats.ScaleDowns > 0 && stats.LastDecision.To == 2 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("unexpected autoscale stats: %+v", stats)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		select {
+		case decision := <-decisions:
+			if decision.From == decision.To {
+				t.Fatalf("unexpected decision: %+v", decision)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("scaling decision was not triggered as an event")
+		}
+	})
+
+	t.Run("grows while every worker is stuck", func(t *testing.T) {
+		eventx.RunApplication(5, 1, true)
+		defer eventx.Close()
+
+		release := make(chan struct{})
+		defer close(release)
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestAutoscaleEntity) error {
+			<-release
+			return nil
+		})
+
+		err := eventx.EnableAutoscale(context.AutoscaleConfig{
+			MinProcessPoolSize: 1,
+			MaxProcessPoolSize: 3,
+			TargetQueueWait:    30 * time.Millisecond,
+			IdleTimeout:        time.Minute,
+			Interval:           10 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the only worker never receives the second event
+		for i := 0; i < 2; i++ {
+			_, _ = eventx.Trigger(TestAutoscaleEntity(i))
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			stats := eventx.AutoscaleStats()
+			if stats.ScaleUps > 0 {
+				if stats.LastDecision.Reason != context.ScalingReasonQueueWait {
+					t.Fatalf("unexpected decision: %+v", *stats.LastDecision)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("unexpected autoscale stats: %+v", stats)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("invalid bounds", func(t *testing.T) {
+		eventx.RunDefaultApplication()
+		defer eventx.Close()
+
+		if err := eventx.EnableAutoscale(context.AutoscaleConfig{MinProcessPoolSize: 3, MaxProcessPoolSize: 2}); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
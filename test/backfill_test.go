@@ -0,0 +1,290 @@
+package test
+
+import (
+	gocontext "context"
+	"fmt"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/backfill"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/eventstore"
+	"github.com/aivyss/eventx/projection"
+	"github.com/aivyss/eventx/wal"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestBackfillEntity struct {
+	Key string
+	Seq int
+}
+
+type testBackfillRecorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *testBackfillRecorder) Trigger(e TestBackfillEntity) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.events = append(r.events, fmt.Sprintf("%s%d", e.Key, e.Seq))
+	return nil
+}
+
+func (r *testBackfillRecorder) list() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]string(nil), r.events...)
+}
+
+// testGatedSource
+//
+// Blocks the reads after the first `gate` ones until the channel is closed.
+type testGatedSource struct {
+	backfill.Source
+	gate   int32
+	reads  *atomic.Int32
+	opened chan struct{}
+}
+
+func (s testGatedSource) Read(ctx gocontext.Context, from int64, limit int) ([]backfill.Record, error) {
+	if s.reads.Add(1) > s.gate {
+		<-s.opened
+	}
+	return s.Source.Read(ctx, from, limit)
+}
+
+func newTestBackfillStore(t *testing.T) *eventstore.Store {
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestBackfillEntity](registry, "test.backfill")
+	_ = codec.Register[TestStoreOpened](registry, "test.store.opened")
+
+	store, _ := eventstore.New(eventstore.NewMemoryBackend(), registry, eventstore.Options{
+		Publish: func(gocontext.Context, any, entity.Metadata) ([]entity.EventContext, error) { return nil, nil },
+	})
+	for _, e := range []any{
+		TestBackfillEntity{Key: "a", Seq: 1},
+		TestBackfillEntity{Key: "b", Seq: 1},
+		TestStoreOpened{},
+		TestBackfillEntity{Key: "a", Seq: 2},
+		TestBackfillEntity{Key: "b", Seq: 2},
+	} {
+		if _, err := store.Append(gocontext.Background(), "s", eventstore.AnyVersion, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return store
+}
+
+func testBackfillKey(envelope entity.Envelope[TestBackfillEntity]) string {
+	return envelope.Entity.Key
+}
+
+func TestBackfillOrdering(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	recorder := &testBackfillRecorder{}
+	target := backfill.NewTarget[TestBackfillEntity](recorder, testBackfillKey)
+	if err := target.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	source := testGatedSource{
+		Source: backfill.EventStoreSource(newTestBackfillStore(t)),
+		// the scan reads 3 batches, the first batch is replayed
+		gate:   4,
+		reads:  &atomic.Int32{},
+		opened: make(chan struct{}),
+	}
+	done := make(chan backfill.Progress, 1)
+	go func() {
+		progress, err := backfill.Run(gocontext.Background(), target, source, backfill.Options{BatchSize: 3, Rate: 1000})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- progress
+	}()
+
+	// wait for the first batch
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.list()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("events: %v", recorder.list())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the live event of a key with history is deferred, the others are not
+	deferred, _ := eventx.Trigger(TestBackfillEntity{Key: "a", Seq: 3})
+	live, _ := eventx.Trigger(TestBackfillEntity{Key: "c", Seq: 1})
+	waitFinished(t, live)
+	select {
+	case <-deferred[0].Finished():
+		t.Fatal("only the live event of a key with history must be deferred")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(source.opened)
+	progress := <-done
+	waitFinished(t, deferred)
+	if deferred[0].IsSkipped() || deferred[0].Err() != nil {
+		t.Fatal("the deferred event must complete once handed to the listener")
+	}
+	if progress.Replayed != 4 || progress.Skipped != 1 || progress.Position != 5 || progress.Head != 5 {
+		t.Fatalf("unexpected progress: %+v", progress)
+	}
+
+	events := recorder.list()
+	index := map[string]int{}
+	for i, e := range events {
+		index[e] = i
+	}
+	if len(events) != 6 || index["a1"] > index["a2"] || index["a2"] > index["a3"] || index["b1"] > index["b2"] {
+		t.Fatalf("unexpected order: %v", events)
+	}
+}
+
+func TestBackfillResume(t *testing.T) {
+	store := newTestBackfillStore(t)
+	checkpoints := projection.NewMemoryCheckpointStore()
+	recorder := &testBackfillRecorder{}
+	target := backfill.NewTarget[TestBackfillEntity](recorder, nil)
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	_, err := backfill.Run(ctx, target, backfill.EventStoreSource(store), backfill.Options{
+		Name:        "recorder",
+		Checkpoints: checkpoints,
+		BatchSize:   2,
+		OnProgress:  func(backfill.Progress) { cancel() },
+	})
+	if err != gocontext.Canceled || len(recorder.list()) != 2 {
+		t.Fatalf("unexpected stop: %v, %v", err, recorder.list())
+	}
+
+	progress, err := backfill.Run(gocontext.Background(), target, backfill.EventStoreSource(store), backfill.Options{
+		Name:        "recorder",
+		Checkpoints: checkpoints,
+		BatchSize:   2,
+	})
+	if err != nil || progress.Replayed != 2 || len(recorder.list()) != 4 {
+		t.Fatalf("unexpected resume: %+v, %v, %v", progress, err, recorder.list())
+	}
+}
+
+func TestBackfillFileSource(t *testing.T) {
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestBackfillEntity](registry, "test.backfill")
+
+	path := filepath.Join(t.TempDir(), "recorded.jsonl")
+	file, _ := os.Create(path)
+	for i := 1; i <= 3; i++ {
+		if err := backfill.WriteRecord(file, registry, TestBackfillEntity{Key: "a", Seq: i}, entity.Metadata{ID: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = file.Close()
+
+	recorder := &testBackfillRecorder{}
+	progress, err := backfill.Run(
+		gocontext.Background(),
+		backfill.NewTarget[TestBackfillEntity](recorder, testBackfillKey),
+		backfill.FileSource(path, registry),
+		backfill.Options{},
+	)
+	if err != nil || progress.Replayed != 3 {
+		t.Fatalf("unexpected backfill: %+v, %v", progress, err)
+	}
+	if events := recorder.list(); len(events) != 3 || events[0] != "a1" || events[2] != "a3" {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
+
+func TestBackfillCanceledHoldsDeferred(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	store := newTestBackfillStore(t)
+	recorder := &testBackfillRecorder{}
+	target := backfill.NewTarget[TestBackfillEntity](recorder, testBackfillKey)
+	if err := target.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	var deferred []entity.EventContext
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	_, err := backfill.Run(ctx, target, backfill.EventStoreSource(store), backfill.Options{
+		BatchSize: 3,
+		OnProgress: func(backfill.Progress) {
+			deferred, _ = eventx.Trigger(TestBackfillEntity{Key: "a", Seq: 3})
+			cancel()
+		},
+	})
+	if err != gocontext.Canceled {
+		t.Fatalf("unexpected stop: %v", err)
+	}
+
+	// a2 has not been replayed yet
+	select {
+	case <-deferred[0].Finished():
+		t.Fatalf("the deferred event must be held: %v", recorder.list())
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, err := backfill.Run(gocontext.Background(), target, backfill.EventStoreSource(store), backfill.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFinished(t, deferred)
+
+	events := recorder.list()
+	index := map[string]int{}
+	for i, e := range events {
+		index[e] = i
+	}
+	if index["a3"] < index["a2"] {
+		t.Fatalf("unexpected order: %v", events)
+	}
+}
+
+func TestBackfillWALSource(t *testing.T) {
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestBackfillEntity](registry, "test.backfill")
+	log, err := wal.Open(t.TempDir(), wal.Options{Codec: registry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	for i := 1; i <= 3; i++ {
+		if _, err := log.Append(TestBackfillEntity{Key: "a", Seq: i}, entity.Metadata{ID: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := backfill.WALSource(log)
+	first, _ := source.Read(gocontext.Background(), 1, 2)
+	// the positions do not move when the earlier entries are completed
+	_ = log.Complete("1")
+	second, _ := source.Read(gocontext.Background(), first[1].Position+1, 2)
+
+	if len(first) != 2 || len(second) != 1 || second[0].Event.(TestBackfillEntity).Seq != 3 {
+		t.Fatalf("unexpected reads: %+v, %+v", first, second)
+	}
+
+	// the end of the snapshot sees the entries appended since, and a new pass the completions
+	if _, err := log.Append(TestBackfillEntity{Key: "a", Seq: 4}, entity.Metadata{ID: "4"}); err != nil {
+		t.Fatal(err)
+	}
+	third, _ := source.Read(gocontext.Background(), second[0].Position+1, 2)
+	again, _ := source.Read(gocontext.Background(), 1, 10)
+	if len(third) != 1 || third[0].Event.(TestBackfillEntity).Seq != 4 || len(again) != 3 {
+		t.Fatalf("unexpected reads: %+v, %+v", third, again)
+	}
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"sync"
+	"testing"
+	"time"
+)
+
+type TestBatchEntity struct {
+	Value int
+}
+
+type TestBatchSizeEntity struct {
+	Value int
+}
+
+type testBatchListener struct {
+	lock    sync.Mutex
+	batches [][]TestBatchEntity
+	then    []int
+	caught  []error
+	fail    func(entities []TestBatchEntity) error
+}
+
+func (l *testBatchListener) TriggerBatch(entities []TestBatchEntity) error {
+	l.lock.Lock()
+	l.batches = append(l.batches, entities)
+	l.lock.Unlock()
+
+	return l.fail(entities)
+}
+
+func (l *testBatchListener) Then(e TestBatchEntity) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.then = append(l.then, e.Value)
+}
+
+func (l *testBatchListener) Catch(err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.caught = append(l.caught, err)
+}
+
+func triggerTestBatch(t *testing.T, n int) []entity.EventContext {
+	var ctxs []entity.EventContext
+	for i := 0; i < n; i++ {
+		triggered, err := eventx.Trigger(TestBatchEntity{Value: i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctxs = append(ctxs, triggered...)
+	}
+
+	return ctxs
+}
+
+func TestBatchEventListener(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	failed := stderrors.New("failed")
+	listener := &testBatchListener{fail: func(entities []TestBatchEntity) error {
+		errs := make(entity.BatchErrors, len(entities))
+		for i, e := range entities {
+			if e.Value%2 == 1 {
+				errs[i] = failed
+			}
+		}
+		return errs
+	}}
+	if err := eventx.RegisterBatchEventListener[TestBatchEntity](listener, entity.BatchOptions{MaxWait: 30 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	// per item failures
+	ctxs := triggerTestBatch(t, 4)
+	waitFinished(t, ctxs)
+	for i, ctx := range ctxs {
+		if (i%2 == 1) != (ctx.Err() == failed) {
+			t.Fatalf("event %d: unexpected error: %v", i, ctx.Err())
+		}
+	}
+
+	listener.lock.Lock()
+	batched := 0
+	for _, batch := range listener.batches {
+		batched += len(batch)
+	}
+	if batched != 4 || len(listener.then) != 2 || len(listener.caught) != 2 {
+		t.Fatalf("batches: %v, then: %v, caught: %v", listener.batches, listener.then, listener.caught)
+	}
+	// a batch failure fails every event
+	listener.fail = func([]TestBatchEntity) error { return failed }
+	listener.lock.Unlock()
+
+	ctxs = triggerTestBatch(t, 3)
+	waitFinished(t, ctxs)
+	for i, ctx := range ctxs {
+		if ctx.Err() != failed {
+			t.Fatalf("event %d: unexpected error: %v", i, ctx.Err())
+		}
+	}
+
+	// the pending batch is processed before the application is closed
+	listener.lock.Lock()
+	listener.fail = func([]TestBatchEntity) error { return nil }
+	listener.lock.Unlock()
+	ctxs = triggerTestBatch(t, 2)
+	eventx.Close()
+	for i, ctx := range ctxs {
+		if !ctx.IsDone() || ctx.Err() != nil {
+			t.Fatalf("event %d: done %v, error: %v", i, ctx.IsDone(), ctx.Err())
+		}
+	}
+}
+
+func TestBatchEventListenerMaxSize(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	sizes := make(chan int, 10)
+	err := eventx.RegisterFuncAsBatchEventListener(func(entities []TestBatchSizeEntity) error {
+		sizes <- len(entities)
+		return nil
+	}, entity.BatchOptions{MaxSize: 5, MaxWait: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ctxs []entity.EventContext
+	for i := 0; i < 10; i++ {
+		triggered, err := eventx.Trigger(TestBatchSizeEntity{Value: i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctxs = append(ctxs, triggered...)
+	}
+	waitFinished(t, ctxs)
+
+	if first, second := <-sizes, <-sizes; first != 5 || second != 5 {
+		t.Fatalf("unexpected batch sizes: %d, %d", first, second)
+	}
+	if stats := eventx.Stats(); stats.Events.Succeeded < 10 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,262 @@
+package test
+
+import (
+	gocontext "context"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/bridge"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestBridgeEntity struct {
+	Seq int
+}
+
+type TestBridgeRemoteEntity struct {
+	Name string
+}
+
+// failingListener fails every Accept until it is closed.
+type failingListener struct {
+	net.Listener
+	accepts atomic.Int32
+	closed  chan struct{}
+}
+
+func (l *failingListener) Accept() (net.Conn, error) {
+	l.accepts.Add(1)
+	select {
+	case <-l.closed:
+		return nil, net.ErrClosed
+	default:
+		return nil, os.ErrDeadlineExceeded
+	}
+}
+
+func (l *failingListener) Close() error {
+	close(l.closed)
+	return nil
+}
+
+func newTestBridgeRegistry() *codec.Registry {
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestBridgeEntity](registry, "test.bridge")
+	_ = codec.Register[TestBridgeRemoteEntity](registry, "test.bridge.remote")
+
+	return registry
+}
+
+func TestBridgeUnixSocket(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	dir, err := os.MkdirTemp("", "eventx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	type received struct {
+		event    any
+		metadata entity.Metadata
+	}
+	remote := make(chan received, 3)
+	receiver, err := bridge.Listen("unix", filepath.Join(dir, "bridge.sock"), bridge.ReceiverOptions{
+		Registry: newTestBridgeRegistry(),
+		Trigger: func(_ gocontext.Context, event any, metadata entity.Metadata) ([]entity.EventContext, error) {
+			remote <- received{event: event, metadata: metadata}
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+
+	sender, err := bridge.NewSender(bridge.SenderOptions{
+		Network:  "unix",
+		Address:  receiver.Addr().String(),
+		Registry: newTestBridgeRegistry(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+	_ = bridge.Forward[TestBridgeEntity](sender)
+
+	var ids []string
+	for i := 1; i <= 3; i++ {
+		ctxs, err := eventx.Trigger(TestBridgeEntity{Seq: i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, ctxs[0].EventID())
+	}
+
+	got := map[string]TestBridgeEntity{}
+	for i := 0; i < 3; i++ {
+		select {
+		case r := <-remote:
+			got[r.metadata.ID] = r.event.(TestBridgeEntity)
+		case <-time.After(time.Second):
+			t.Fatal("event was not bridged")
+		}
+	}
+	for i, id := range ids {
+		if got[id].Seq != i+1 {
+			t.Fatalf("unexpected events: %+v", got)
+		}
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Second)
+	defer cancel()
+	if err := sender.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBridgeReconnect(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	received := make(chan entity.Envelope[TestBridgeRemoteEntity], 3)
+	_ = eventx.RegisterFuncEnvelopeAsEventListener(func(_ gocontext.Context, envelope entity.Envelope[TestBridgeRemoteEntity]) error {
+		received <- envelope
+		return nil
+	})
+
+	// reserve a free port, the receiver is not listening yet
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := listener.Addr().String()
+	_ = listener.Close()
+
+	sender, err := bridge.NewSender(bridge.SenderOptions{
+		Network:    "tcp",
+		Address:    address,
+		Registry:   newTestBridgeRegistry(),
+		BufferSize: 2,
+		MinBackoff: 5 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	for _, name := range []string{"a", "b"} {
+		if err := sender.Send(TestBridgeRemoteEntity{Name: name}, entity.Metadata{ID: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sender.Send(TestBridgeRemoteEntity{Name: "c"}, entity.Metadata{}); err == nil {
+		t.Fatal("the buffer must be full")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if sender.Pending() != 2 {
+		t.Fatalf("pending: %d", sender.Pending())
+	}
+
+	// the remote events are injected through the regular trigger path
+	receiver, err := bridge.Listen("tcp", address, bridge.ReceiverOptions{Registry: newTestBridgeRegistry()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+
+	names := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case envelope := <-received:
+			if envelope.ID != envelope.Entity.Name {
+				t.Fatalf("unexpected envelope: %+v", envelope)
+			}
+			names[envelope.ID] = true
+		case <-time.After(time.Second):
+			t.Fatal("event was not bridged")
+		}
+	}
+	if !names["a"] || !names["b"] {
+		t.Fatalf("unexpected events: %v", names)
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Second)
+	defer cancel()
+	if err := sender.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBridgeDroppingConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// the connections are dropped as soon as they are accepted
+	accepted := make(chan struct{}, 1000)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+			accepted <- struct{}{}
+		}
+	}()
+
+	sender, err := bridge.NewSender(bridge.SenderOptions{
+		Network:    "tcp",
+		Address:    listener.Addr().String(),
+		Registry:   newTestBridgeRegistry(),
+		MinBackoff: 20 * time.Millisecond,
+		MaxBackoff: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+
+	// 20 + 40 + 80 ms
+	time.Sleep(200 * time.Millisecond)
+	if len(accepted) > 5 {
+		t.Fatalf("the reconnections must back off: %d", len(accepted))
+	}
+}
+
+func TestBridgeFailingAccept(t *testing.T) {
+	listener := &failingListener{closed: make(chan struct{})}
+	var errs atomic.Int32
+	receiver, err := bridge.NewReceiver(listener, bridge.ReceiverOptions{
+		Registry:   newTestBridgeRegistry(),
+		OnError:    func(error) { errs.Add(1) },
+		MinBackoff: 20 * time.Millisecond,
+		MaxBackoff: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 20 + 40 + 80 ms
+	time.Sleep(200 * time.Millisecond)
+	if accepts := listener.accepts.Load(); accepts > 5 || errs.Load() == 0 {
+		t.Fatalf("the accepts must back off: %d, errors: %d", accepts, errs.Load())
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- receiver.Close()
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("the backoff must not hold Close")
+	}
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	gocontext "context"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/wal"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type TestCodecEntityV1 struct {
+	Name string
+}
+
+type TestCodecEntityV2 struct {
+	Name  string
+	Count int
+}
+
+type TestCodecGobEntity struct {
+	Values []int
+}
+
+var _ wal.Codec = (*codec.Registry)(nil)
+
+func TestCodecRegistry(t *testing.T) {
+	registry := codec.NewRegistry(nil)
+	if err := codec.Register[TestCodecEntityV1](registry, "test.codec"); err != nil {
+		t.Fatal(err)
+	}
+	if err := codec.Register[TestCodecEntityV2](registry, "test.codec", codec.WithVersion(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := codec.Register[TestCodecGobEntity](registry, "test.codec.gob", codec.WithCodec(codec.Gob)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := codec.Register[TestCodecEntityV1](registry, "other"); err != errors.AlreadyRegisteredErr {
+		t.Fatalf("a type must be registered once: %v", err)
+	}
+	if err := codec.Register[int](registry, "test.codec", codec.WithVersion(2)); err != errors.AlreadyRegisteredErr {
+		t.Fatalf("a name and version must be taken once: %v", err)
+	}
+
+	for _, event := range []any{
+		TestCodecEntityV1{Name: "a"},
+		TestCodecEntityV2{Name: "b", Count: 2},
+		TestCodecGobEntity{Values: []int{1, 2}},
+	} {
+		wireName, payload, err := registry.Encode(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, ok := registry.Lookup(reflect.TypeOf(event))
+		if !ok || info.WireName() != wireName {
+			t.Fatalf("unexpected wire name: %s", wireName)
+		}
+
+		decoded, err := registry.Decode(wireName, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, event) {
+			t.Fatalf("decoded: %#v, expected: %#v", decoded, event)
+		}
+	}
+
+	if info, ok := registry.Resolve("test.codec"); !ok || info.Type != reflect.TypeOf(TestCodecEntityV1{}) {
+		t.Fatal("a name without a version must resolve to the first version")
+	}
+	if _, _, err := registry.Encode(1); err != errors.UnknownEventTypeErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := registry.Decode("unknown/v1", nil); err != errors.UnknownEventTypeErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTriggerEncoded(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	registry := codec.NewRegistry(codec.JSON)
+	_ = codec.Register[TestCodecEntityV2](registry, "test.codec", codec.WithVersion(2))
+
+	received := make(chan entity.Envelope[TestCodecEntityV2], 1)
+	_ = eventx.RegisterFuncEnvelopeAsEventListener(func(_ gocontext.Context, envelope entity.Envelope[TestCodecEntityV2]) error {
+		received <- envelope
+		return nil
+	})
+
+	_, err := eventx.TriggerEncoded(
+		gocontext.Background(),
+		registry,
+		"test.codec/v2",
+		[]byte(`{"Name":"a","Count":3}`),
+		entity.Metadata{ID: "encoded"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case envelope := <-received:
+		if envelope.ID != "encoded" || envelope.Entity != (TestCodecEntityV2{Name: "a", Count: 3}) {
+			t.Fatalf("unexpected envelope: %+v", envelope)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event was not received")
+	}
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	gocontext "context"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"testing"
+	"time"
+)
+
+type TestEnvelopeEntity int
+type TestEnvelopeChainedEntity int
+
+func TestEnvelope(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	received := make(chan entity.Envelope[TestEnvelopeEntity], 1)
+	chained := make(chan entity.Envelope[TestEnvelopeChainedEntity], 1)
+	_ = eventx.RegisterFuncEnvelopeAsEventListener(func(ctx gocontext.Context, envelope entity.Envelope[TestEnvelopeEntity]) error {
+		received <- envelope
+		_, err := eventx.TriggerContext(ctx, TestEnvelopeChainedEntity(envelope.Entity))
+		return err
+	})
+	_ = eventx.RegisterFuncEnvelopeAsEventListener(func(ctx gocontext.Context, envelope entity.Envelope[TestEnvelopeChainedEntity]) error {
+		chained <- envelope
+		return nil
+	})
+
+	eventCtxs, err := eventx.TriggerEnvelope(gocontext.Background(), entity.Envelope[TestEnvelopeEntity]{
+		Metadata: entity.Metadata{Headers: map[string]string{"tenant": "a"}},
+		Entity:   1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first entity.Envelope[TestEnvelopeEntity]
+	select {
+	case first = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("envelope was not received")
+	}
+	if first.ID == "" || first.ID != eventCtxs[0].EventID() || first.CorrelationID != first.ID || first.CausationID != "" {
+		t.Fatalf("unexpected metadata: %+v", first.Metadata)
+	}
+	if first.Headers["tenant"] != "a" || first.OccurredAt.IsZero() || first.EnqueuedAt.IsZero() || first.Entity != 1 {
+		t.Fatalf("unexpected envelope: %+v", first)
+	}
+
+	select {
+	case second := <-chained:
+		if second.ID == first.ID || second.CorrelationID != first.ID || second.CausationID != first.ID {
+			t.Fatalf("unexpected chained metadata: %+v", second.Metadata)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("chained envelope was not received")
+	}
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	gocontext "context"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/eventstore"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type TestStoreOpened struct {
+	Owner string
+}
+
+type TestStoreDeposited struct {
+	Amount int
+}
+
+func newTestStoreRegistry() *codec.Registry {
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestStoreOpened](registry, "test.store.opened")
+	_ = codec.Register[TestStoreDeposited](registry, "test.store.deposited")
+
+	return registry
+}
+
+func versions(records []eventstore.RecordedEvent) []int64 {
+	var result []int64
+	for _, record := range records {
+		result = append(result, record.Version)
+	}
+	return result
+}
+
+func testEventStore(t *testing.T, backend eventstore.Backend) {
+	ctx := gocontext.Background()
+	store, err := eventstore.New(backend, newTestStoreRegistry(), eventstore.Options{
+		Publish: func(gocontext.Context, any, entity.Metadata) ([]entity.EventContext, error) { return nil, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Append(ctx, "account-1", eventstore.NoStream, TestStoreOpened{Owner: "a"}, TestStoreDeposited{Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append(ctx, "account-2", eventstore.NoStream, TestStoreOpened{Owner: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append(ctx, "account-1", eventstore.NoStream, TestStoreDeposited{Amount: 2}); err != errors.WrongExpectedVersionErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Append(ctx, "account-1", 2, TestStoreDeposited{Amount: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append(ctx, "account-1", eventstore.AnyVersion, TestStoreDeposited{Amount: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	forward, _ := store.ReadStream(ctx, "account-1", 2, eventstore.Forward, 2)
+	backward, _ := store.ReadStream(ctx, "account-1", 0, eventstore.Backward, 3)
+	if v := versions(forward); len(v) != 2 || v[0] != 2 || v[1] != 3 {
+		t.Fatalf("forward: %v", v)
+	}
+	if v := versions(backward); len(v) != 3 || v[0] != 4 || v[2] != 2 {
+		t.Fatalf("backward: %v", v)
+	}
+
+	all, _ := store.ReadAll(ctx, 3, 0)
+	if len(all) != 3 || all[0].StreamID != "account-2" || all[0].Position != 3 || all[2].Position != 5 {
+		t.Fatalf("unexpected events: %+v", all)
+	}
+	event, err := store.Decode(all[2])
+	if err != nil || event != (TestStoreDeposited{Amount: 3}) {
+		t.Fatalf("unexpected event: %v, %v", event, err)
+	}
+}
+
+func TestEventStoreMemory(t *testing.T) {
+	testEventStore(t, eventstore.NewMemoryBackend())
+}
+
+func TestEventStoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+	backend, err := eventstore.OpenFileBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEventStore(t, backend)
+	_ = backend.Close()
+
+	// simulate a write interrupted by a crash
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	_, _ = file.Write([]byte{0, 0, 0, 9, 1})
+	_ = file.Close()
+
+	backend, err = eventstore.OpenFileBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	all, _ := backend.ReadAll(gocontext.Background(), 0, 0)
+	if len(all) != 5 {
+		t.Fatalf("events: %d", len(all))
+	}
+	records, err := backend.Append(gocontext.Background(), "account-1", 4, []eventstore.EventData{{TypeName: "test.store.deposited/v1", Payload: []byte(`{"Amount":4}`)}})
+	if err != nil || records[0].Position != 6 || records[0].Version != 5 {
+		t.Fatalf("unexpected append: %+v, %v", records, err)
+	}
+}
+
+func TestEventStoreFileCorruptedLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+	backend, err := eventstore.OpenFileBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposit := []eventstore.EventData{{TypeName: "test.store.deposited/v1", Payload: []byte(`{"Amount":1}`)}}
+	if _, err := backend.Append(gocontext.Background(), "account-1", eventstore.NoStream, deposit); err != nil {
+		t.Fatal(err)
+	}
+	_ = backend.Close()
+
+	// a header announcing a 4GB frame must not be allocated
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	_, _ = file.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 1, 2})
+	_ = file.Close()
+
+	backend, err = eventstore.OpenFileBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	if records, err := backend.Append(gocontext.Background(), "account-1", 1, deposit); err != nil || records[0].Position != 2 {
+		t.Fatalf("unexpected append: %+v, %v", records, err)
+	}
+}
+
+func TestEventStorePublish(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	received := make(chan entity.Envelope[TestStoreDeposited], 1)
+	_ = eventx.RegisterFuncEnvelopeAsEventListener(func(_ gocontext.Context, envelope entity.Envelope[TestStoreDeposited]) error {
+		received <- envelope
+		return nil
+	})
+
+	store, _ := eventstore.New(eventstore.NewMemoryBackend(), newTestStoreRegistry(), eventstore.Options{})
+	records, err := store.Append(gocontext.Background(), "account-1", eventstore.NoStream, TestStoreOpened{}, TestStoreDeposited{Amount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case envelope := <-received:
+		if envelope.ID != records[1].Metadata.ID || envelope.Entity.Amount != 1 {
+			t.Fatalf("unexpected envelope: %+v", envelope)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event was not published")
+	}
+
+	// a rejected append is not published
+	if _, err := store.Append(gocontext.Background(), "account-1", eventstore.NoStream, TestStoreDeposited{Amount: 2}); err == nil {
+		t.Fatal("the append must be rejected")
+	}
+	select {
+	case envelope := <-received:
+		t.Fatalf("unexpected envelope: %+v", envelope)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
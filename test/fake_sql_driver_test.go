@@ -0,0 +1,230 @@
+package test
+
+import (
+	gocontext "context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeOutboxDB
+//
+// An in-memory database/sql driver understanding the statements of the outbox package.
+type fakeOutboxDB struct {
+	lock sync.Mutex
+	rows []*fakeOutboxRow
+	seq  int64
+}
+
+type fakeOutboxRow struct {
+	id           string
+	seq          int64
+	typeName     string
+	payload      []byte
+	metadata     string
+	createdAt    time.Time
+	dispatchedAt *time.Time
+}
+
+func openFakeOutboxDB() (*sql.DB, *fakeOutboxDB) {
+	db := &fakeOutboxDB{}
+	return sql.OpenDB(fakeConnector{db: db}), db
+}
+
+func (db *fakeOutboxDB) count(dispatched bool) int {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	n := 0
+	for _, row := range db.rows {
+		if (row.dispatchedAt != nil) == dispatched {
+			n += 1
+		}
+	}
+
+	return n
+}
+
+func (db *fakeOutboxDB) exec(query string, args []driver.Value) (int64, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "INSERT"):
+		db.seq += 1
+		db.rows = append(db.rows, &fakeOutboxRow{
+			id:        args[0].(string),
+			seq:       db.seq,
+			typeName:  args[1].(string),
+			payload:   args[2].([]byte),
+			metadata:  args[3].(string),
+			createdAt: args[4].(time.Time),
+		})
+		return 1, nil
+	case strings.HasPrefix(query, "UPDATE"):
+		for _, row := range db.rows {
+			if row.id == args[1].(string) {
+				at := args[0].(time.Time)
+				row.dispatchedAt = &at
+				return 1, nil
+			}
+		}
+		return 0, nil
+	case strings.HasPrefix(query, "DELETE"):
+		var kept []*fakeOutboxRow
+		for _, row := range db.rows {
+			if row.dispatchedAt == nil || !row.dispatchedAt.Before(args[0].(time.Time)) {
+				kept = append(kept, row)
+			}
+		}
+		deleted := int64(len(db.rows) - len(kept))
+		db.rows = kept
+		return deleted, nil
+	}
+
+	return 0, driver.ErrSkip
+}
+
+func (db *fakeOutboxDB) query(query string) (driver.Rows, error) {
+	fields := strings.Fields(query)
+	limit, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	var pending []*fakeOutboxRow
+	for _, row := range db.rows {
+		if row.dispatchedAt == nil {
+			pending = append(pending, row)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].seq < pending[j].seq
+	})
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	values := make([][]driver.Value, 0, len(pending))
+	for _, row := range pending {
+		values = append(values, []driver.Value{row.id, row.typeName, row.payload, row.metadata})
+	}
+
+	return &fakeRows{values: values}, nil
+}
+
+type fakeConnector struct {
+	db *fakeOutboxDB
+}
+
+func (c fakeConnector) Connect(gocontext.Context) (driver.Conn, error) {
+	return &fakeConn{db: c.db}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, driver.ErrBadConn
+}
+
+// fakeConn
+//
+// Buffers the statements of a transaction until it commits.
+type fakeConn struct {
+	db      *fakeOutboxDB
+	pending []func()
+	inTx    bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	for _, f := range c.pending {
+		f()
+	}
+	c.pending = nil
+	c.inTx = false
+
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.pending = nil
+	c.inTx = false
+
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.conn.inTx {
+		s.conn.pending = append(s.conn.pending, func() {
+			_, _ = s.conn.db.exec(s.query, args)
+		})
+		return driver.RowsAffected(1), nil
+	}
+
+	affected, err := s.conn.db.exec(s.query, args)
+	return driver.RowsAffected(affected), err
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return s.conn.db.query(s.query)
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "type_name", "payload", "metadata"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
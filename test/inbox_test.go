@@ -0,0 +1,237 @@
+package test
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/inbox"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestInboxEntity struct {
+	OrderID string
+	Fail    bool
+}
+
+func waitFinished(t *testing.T, ctxs []entity.EventContext) {
+	for _, ctx := range ctxs {
+		select {
+		case <-ctx.Finished():
+		case <-time.After(time.Second):
+			t.Fatal("event was not finished")
+		}
+	}
+}
+
+func TestInboxGuard(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	var triggered, then atomic.Int32
+	listener := entity.BuildSuccessEventListener(func(e TestInboxEntity) error {
+		if e.Fail {
+			return stderrors.New("failed")
+		}
+		triggered.Add(1)
+		return nil
+	}, func(TestInboxEntity) {
+		then.Add(1)
+	})
+	_ = eventx.RegisterEventListener(inbox.Guard[TestInboxEntity]("orders", listener, inbox.NewMemoryStore(time.Minute), nil))
+
+	trigger := func(id string, e TestInboxEntity) entity.EventContext {
+		ctxs, err := eventx.TriggerEnvelope(gocontext.Background(), entity.Envelope[TestInboxEntity]{
+			Metadata: entity.Metadata{ID: id},
+			Entity:   e,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitFinished(t, ctxs)
+		return ctxs[0]
+	}
+
+	if ctx := trigger("1", TestInboxEntity{}); ctx.IsSkipped() {
+		t.Fatal("the first delivery must be processed")
+	}
+	if ctx := trigger("1", TestInboxEntity{}); !ctx.IsSkipped() || ctx.Err() != nil {
+		t.Fatal("the duplicate must be skipped")
+	}
+	if triggered.Load() != 1 || then.Load() != 1 {
+		t.Fatalf("triggered: %d, then: %d", triggered.Load(), then.Load())
+	}
+
+	// a failed delivery is released, so it can be retried
+	if ctx := trigger("2", TestInboxEntity{Fail: true}); ctx.Err() == nil {
+		t.Fatal("the delivery must fail")
+	}
+	if ctx := trigger("2", TestInboxEntity{}); ctx.IsSkipped() || ctx.Err() != nil {
+		t.Fatal("the retry must be processed")
+	}
+	if triggered.Load() != 2 {
+		t.Fatalf("triggered: %d", triggered.Load())
+	}
+}
+
+// failingCommitStore fails the first commit.
+type failingCommitStore struct {
+	inbox.Store
+	failed atomic.Bool
+}
+
+func (s *failingCommitStore) Commit(key string) error {
+	if s.failed.CompareAndSwap(false, true) {
+		return stderrors.New("commit failed")
+	}
+
+	return s.Store.Commit(key)
+}
+
+func TestInboxCommitFailure(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	var triggered atomic.Int32
+	store := &failingCommitStore{Store: inbox.NewMemoryStore(time.Minute)}
+	_ = eventx.RegisterEventListener(inbox.Guard[TestInboxEntity]("orders", entity.BuildEventListener(func(TestInboxEntity) error {
+		triggered.Add(1)
+		return nil
+	}), store, nil))
+
+	trigger := func() entity.EventContext {
+		ctxs, err := eventx.TriggerEnvelope(gocontext.Background(), entity.Envelope[TestInboxEntity]{
+			Metadata: entity.Metadata{ID: "1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitFinished(t, ctxs)
+		return ctxs[0]
+	}
+
+	if ctx := trigger(); ctx.Err() == nil {
+		t.Fatal("the delivery must fail with the commit")
+	}
+	// the key has been released
+	if ctx := trigger(); ctx.IsSkipped() || ctx.Err() != nil {
+		t.Fatal("the redelivery must be processed")
+	}
+	if ctx := trigger(); !ctx.IsSkipped() {
+		t.Fatal("the duplicate must be skipped")
+	}
+	if triggered.Load() != 2 {
+		t.Fatalf("triggered: %d", triggered.Load())
+	}
+}
+
+func TestInboxInProgress(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	release := make(chan struct{})
+	var triggered atomic.Int32
+	store := inbox.NewMemoryStore(time.Minute)
+	for i := 0; i < 2; i++ {
+		_ = eventx.RegisterEventListener(inbox.Guard[TestInboxEntity]("orders", entity.BuildEventListener(func(TestInboxEntity) error {
+			triggered.Add(1)
+			<-release
+			return nil
+		}), store, nil))
+	}
+
+	ctxs, err := eventx.TriggerEnvelope(gocontext.Background(), entity.Envelope[TestInboxEntity]{
+		Metadata: entity.Metadata{ID: "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the listener which did not reserve the key fails without waiting for the other one
+	var inProgress entity.EventContext
+	select {
+	case <-ctxs[0].Finished():
+		inProgress = ctxs[0]
+	case <-ctxs[1].Finished():
+		inProgress = ctxs[1]
+	case <-time.After(time.Second):
+		t.Fatal("the duplicate was not finished")
+	}
+	close(release)
+	waitFinished(t, ctxs)
+
+	if inProgress.IsSkipped() || !stderrors.Is(inProgress.Err(), errors.InProgressErr) {
+		t.Fatalf("the duplicate must fail as in progress: %v", inProgress.Err())
+	}
+	if triggered.Load() != 1 {
+		t.Fatalf("triggered: %d", triggered.Load())
+	}
+}
+
+func TestInboxKeyFunc(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	var triggered atomic.Int32
+	_ = eventx.RegisterEventListener(inbox.Guard[TestInboxEntity](
+		"orders",
+		entity.BuildEventListener(func(TestInboxEntity) error {
+			triggered.Add(1)
+			return nil
+		}),
+		inbox.NewMemoryStore(0),
+		func(envelope entity.Envelope[TestInboxEntity]) string { return envelope.Entity.OrderID },
+	))
+
+	for _, orderID := range []string{"a", "a", "b"} {
+		ctxs, _ := eventx.Trigger(TestInboxEntity{OrderID: orderID})
+		waitFinished(t, ctxs)
+	}
+	if triggered.Load() != 2 {
+		t.Fatalf("triggered: %d", triggered.Load())
+	}
+}
+
+func TestInboxStores(t *testing.T) {
+	memory := inbox.NewMemoryStore(20 * time.Millisecond)
+	if ok, _ := memory.Reserve("a"); !ok {
+		t.Fatal("the key must be reserved")
+	}
+	if ok, err := memory.Reserve("a"); ok || !stderrors.Is(err, errors.InProgressErr) {
+		t.Fatalf("a reserved key must be in progress: %v", err)
+	}
+	_ = memory.Commit("a")
+	if ok, _ := memory.Reserve("a"); ok {
+		t.Fatal("a processed key must not be reserved again")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if ok, _ := memory.Reserve("a"); !ok {
+		t.Fatal("an expired key must be reserved again")
+	}
+
+	path := filepath.Join(t.TempDir(), "inbox")
+	file, err := inbox.OpenFileStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.Reserve("a")
+	_ = file.Commit("a")
+	_, _ = file.Reserve("b")
+	_ = file.Close()
+
+	file, err = inbox.OpenFileStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if ok, _ := file.Reserve("a"); ok {
+		t.Fatal("a processed key must survive the restart")
+	}
+	if ok, _ := file.Reserve("b"); !ok {
+		t.Fatal("a reservation must not survive the restart")
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/ingress"
+	"github.com/aivyss/eventx/signature"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type TestIngressEntity struct {
+	Name string `json:"name"`
+}
+
+func (e TestIngressEntity) Validate() error {
+	if e.Name == "" {
+		return stderrors.New("name is required")
+	}
+	return nil
+}
+
+func TestIngressHandler(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	_ = eventx.RegisterFuncAsEventListener(func(e TestIngressEntity) error {
+		if e.Name == "fail" {
+			return stderrors.New("failed")
+		}
+		return nil
+	})
+
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestIngressEntity](registry, "test.ingress")
+	secret := []byte("secret")
+	server := httptest.NewServer(ingress.NewHandler(registry, ingress.Options{Secret: secret}))
+	defer server.Close()
+
+	post := func(query string, body string, signed bool) (int, ingress.Response) {
+		request, _ := http.NewRequest(http.MethodPost, server.URL+query, bytes.NewBufferString(body))
+		if signed {
+			request.Header.Set(signature.Header, signature.Sign(secret, []byte(body)))
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+
+		var decoded ingress.Response
+		_ = json.NewDecoder(response.Body).Decode(&decoded)
+		return response.StatusCode, decoded
+	}
+
+	status, response := post("", `{"type":"test.ingress/v1","payload":{"name":"a"},"id":"ingress-1"}`, true)
+	if status != http.StatusAccepted || len(response.EventIDs) != 1 || response.EventIDs[0] != "ingress-1" {
+		t.Fatalf("unexpected response: %d, %+v", status, response)
+	}
+
+	status, response = post("?wait=true", `{"type":"test.ingress","payload":{"name":"b"}}`, true)
+	if status != http.StatusOK || len(response.Results) != 1 || response.Results[0].Error != "" {
+		t.Fatalf("unexpected response: %d, %+v", status, response)
+	}
+
+	status, response = post("?wait=1s", `{"type":"test.ingress","payload":{"name":"fail"}}`, true)
+	if status != http.StatusInternalServerError || response.Results[0].Error != "failed" {
+		t.Fatalf("unexpected response: %d, %+v", status, response)
+	}
+
+	for _, c := range []struct {
+		body   string
+		signed bool
+		status int
+	}{
+		{`{"type":"test.ingress","payload":{"name":"a"}}`, false, http.StatusUnauthorized},
+		{`{"type":"unknown","payload":{}}`, true, http.StatusBadRequest},
+		{`{"type":"test.ingress","payload":{"name":""}}`, true, http.StatusUnprocessableEntity},
+		{`not json`, true, http.StatusBadRequest},
+	} {
+		if status, response := post("", c.body, c.signed); status != c.status {
+			t.Fatalf("%s: unexpected response: %d, %+v", c.body, status, response)
+		}
+	}
+
+	for _, query := range []string{"?wait=0s", "?wait=-1s", "?wait=soon"} {
+		if status, response := post(query, `{"type":"test.ingress","payload":{"name":"a"}}`, true); status != http.StatusBadRequest {
+			t.Fatalf("%s: unexpected response: %d, %+v", query, status, response)
+		}
+	}
+
+	limited := httptest.NewServer(ingress.NewHandler(registry, ingress.Options{MaxBodySize: 8}))
+	defer limited.Close()
+	tooLarge, err := http.Post(limited.URL, "application/json", bytes.NewBufferString(`{"type":"test.ingress","payload":{"name":"a"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = tooLarge.Body.Close()
+	if tooLarge.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status: %d", tooLarge.StatusCode)
+	}
+}
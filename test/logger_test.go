@@ -0,0 +1,89 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/aivyss/eventx"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type TestLoggerEntity int
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buffer.String()
+}
+
+func TestLogger(t *testing.T) {
+	var buffer syncBuffer
+	eventx.SetLogger(slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	eventx.SetSlowListenerThreshold(5 * time.Millisecond)
+	defer eventx.SetLogger(nil)
+	defer eventx.SetSlowListenerThreshold(0)
+
+	eventx.RunDefaultApplication()
+
+	caught := make(chan error, 1)
+	_ = eventx.RegisterFuncCatchAsEventListener(
+		func(entity TestLoggerEntity) error {
+			switch entity {
+			case 0:
+				panic("boom")
+			case 1:
+				return errors.New("failure")
+			default:
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			}
+		},
+		func(err error) {
+			if strings.Contains(err.Error(), "boom") {
+				caught <- err
+			}
+		},
+	)
+
+	for i := 0; i < 3; i++ {
+		_, _ = eventx.Trigger(TestLoggerEntity(i))
+	}
+	eventx.Close()
+
+	select {
+	case <-caught:
+	case <-time.After(time.Second):
+		t.Fatal("panic was not passed to catch")
+	}
+
+	logs := buffer.String()
+	for _, msg := range []string{
+		`"msg":"eventx event channel is running"`,
+		`"msg":"eventx event listener panicked","listener":"test.TestLoggerEntity#0"`,
+		`"msg":"eventx event listener failed","listener":"test.TestLoggerEntity#0","eventType":"test.TestLoggerEntity"`,
+		`"error":"failure"`,
+		`"msg":"eventx slow event listener"`,
+		`"msg":"eventx is closed"`,
+	} {
+		if !strings.Contains(logs, msg) {
+			t.Fatalf("missing %s in\n%s", msg, logs)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/metrics"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TestMetricsEntity int
+
+func TestMetricsHandler(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	_ = eventx.RegisterFuncAsEventListener(func(entity TestMetricsEntity) error {
+		return nil
+	})
+	_, _ = eventx.Trigger(TestMetricsEntity(1))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for eventx.Stats().Events.Succeeded != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("event was not processed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type: %s", recorder.Header().Get("Content-Type"))
+	}
+
+	body := recorder.Body.String()
+	for _, line := range []string{
+		`eventx_application_status{status="Running"} 1`,
+		`eventx_queue_capacity{queue="process"} 5`,
+		`eventx_workers{pool="process"} 10`,
+		`eventx_events_total{event_type="test.TestMetricsEntity",outcome="succeeded"} 1`,
+		`eventx_events_triggered_total{event_type="test.TestMetricsEntity"} 1`,
+		`eventx_listener_events_triggered_total{listener="test.TestMetricsEntity#0",event_type="test.TestMetricsEntity"} 1`,
+		`eventx_listener_events_total{listener="test.TestMetricsEntity#0",event_type="test.TestMetricsEntity",outcome="succeeded"} 1`,
+		`eventx_listener_execution_seconds_bucket{listener="test.TestMetricsEntity#0",event_type="test.TestMetricsEntity",le="+Inf"} 1`,
+		`eventx_queue_wait_seconds_count 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("missing line %q in\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, `outcome="triggered"`) {
+		t.Fatalf("triggered counted as an outcome in\n%s", body)
+	}
+}
@@ -0,0 +1,206 @@
+package test
+
+import (
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type TestOperatorDebounced struct {
+	Key   string
+	Value int
+}
+
+type TestOperatorThrottled struct {
+	Key   string
+	Value int
+}
+
+type TestOperatorCoalesced struct {
+	Key   string
+	Value int
+}
+
+type testOperatorLog struct {
+	lock   sync.Mutex
+	values []int
+}
+
+func (l *testOperatorLog) add(value int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.values = append(l.values, value)
+}
+
+func (l *testOperatorLog) get() []int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]int(nil), l.values...)
+}
+
+func triggerTestOperator[E any](t *testing.T, events ...E) []entity.EventContext {
+	var ctxs []entity.EventContext
+	for _, e := range events {
+		triggered, err := eventx.Trigger(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctxs = append(ctxs, triggered...)
+	}
+
+	return ctxs
+}
+
+func assertSuperseded(t *testing.T, ctxs []entity.EventContext, superseded ...bool) {
+	for i, ctx := range ctxs {
+		if ctx.IsSuperseded() != superseded[i] || ctx.Err() != nil {
+			t.Fatalf("event %d: superseded %v, error %v", i, ctx.IsSuperseded(), ctx.Err())
+		}
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	log := &testOperatorLog{}
+	listener := entity.BuildEventListener(func(e TestOperatorDebounced) error {
+		log.add(e.Value)
+		return nil
+	})
+	err := eventx.RegisterEventListener(listener, entity.Debounce(50*time.Millisecond, func(e TestOperatorDebounced) string {
+		return e.Key
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ctxs []entity.EventContext
+	for i := 0; i < 4; i++ {
+		ctxs = append(ctxs, triggerTestOperator(t, TestOperatorDebounced{Key: "a", Value: i})...)
+		time.Sleep(10 * time.Millisecond)
+	}
+	ctxs = append(ctxs, triggerTestOperator(t, TestOperatorDebounced{Key: "b", Value: 10})...)
+	waitFinished(t, ctxs)
+
+	values := log.get()
+	if len(values) != 2 || !((values[0] == 3 && values[1] == 10) || (values[0] == 10 && values[1] == 3)) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	assertSuperseded(t, ctxs, true, true, true, false, false)
+}
+
+func TestThrottle(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	log := &testOperatorLog{}
+	listener := entity.BuildEventListener(func(e TestOperatorThrottled) error {
+		log.add(e.Value)
+		return nil
+	})
+	err := eventx.RegisterEventListener(listener, entity.Throttle(100*time.Millisecond, func(e TestOperatorThrottled) string {
+		return e.Key
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := triggerTestOperator(t, TestOperatorThrottled{Key: "a", Value: 0})
+	waitFinished(t, first)
+
+	ctxs := triggerTestOperator(t,
+		TestOperatorThrottled{Key: "a", Value: 1},
+		TestOperatorThrottled{Key: "a", Value: 2},
+		TestOperatorThrottled{Key: "a", Value: 3},
+	)
+	waitFinished(t, ctxs)
+
+	if values := log.get(); !reflect.DeepEqual(values, []int{0, 3}) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	assertSuperseded(t, append(first, ctxs...), false, true, true, false)
+}
+
+func TestCoalesce(t *testing.T) {
+	// a single worker, so that the events are queued behind the blocking one
+	eventx.RunApplication(10, 1, true)
+	defer eventx.Close()
+
+	log := &testOperatorLog{}
+	release := make(chan struct{})
+	listener := entity.BuildEventListener(func(e TestOperatorCoalesced) error {
+		if e.Key == "block" {
+			<-release
+		}
+		log.add(e.Value)
+		return nil
+	})
+	err := eventx.RegisterEventListener(listener, entity.Coalesce(func(e TestOperatorCoalesced) string {
+		return e.Key
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocking := triggerTestOperator(t, TestOperatorCoalesced{Key: "block"})
+	time.Sleep(20 * time.Millisecond)
+	ctxs := triggerTestOperator(t,
+		TestOperatorCoalesced{Key: "a", Value: 1},
+		TestOperatorCoalesced{Key: "a", Value: 2},
+		TestOperatorCoalesced{Key: "b", Value: 10},
+		TestOperatorCoalesced{Key: "a", Value: 3},
+	)
+	close(release)
+	waitFinished(t, append(blocking, ctxs...))
+
+	if values := log.get(); !reflect.DeepEqual(values, []int{0, 10, 3}) && !reflect.DeepEqual(values, []int{0, 3, 10}) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	assertSuperseded(t, ctxs, true, true, false, false)
+}
+
+func TestCoalesceDropped(t *testing.T) {
+	var values []int
+	listener := entity.BuildEventListener(func(e TestOperatorCoalesced) error {
+		values = append(values, e.Value)
+		return nil
+	})
+	factory := entity.Coalesce(func(e TestOperatorCoalesced) string {
+		return e.Key
+	})(entity.NewEventSetFactory[TestOperatorCoalesced](listener))
+
+	newSet := func(value int) entity.QueueAwareEventSet {
+		set, ok := factory(TestOperatorCoalesced{Key: "a", Value: value}, entity.Metadata{})
+		if !ok {
+			t.Fatal("not created")
+		}
+		return set.(entity.QueueAwareEventSet)
+	}
+
+	// the later event has been canceled before its execution
+	first, second := newSet(1), newSet(2)
+	first.Queued()
+	second.Queued()
+	second.Dropped()
+	first.Runner()
+
+	// the later event has never been queued
+	third, _ := newSet(3), newSet(4)
+	third.Queued()
+	third.Runner()
+
+	// the earlier event is dequeued after the later one has been executed
+	fifth, sixth := newSet(5), newSet(6)
+	fifth.Queued()
+	sixth.Queued()
+	sixth.Runner()
+	fifth.Runner()
+
+	if !reflect.DeepEqual(values, []int{1, 3, 6}) || !fifth.Context().IsSuperseded() {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
@@ -0,0 +1,221 @@
+package test
+
+import (
+	gocontext "context"
+	"database/sql"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/outbox"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestOutboxEntity struct {
+	Name string
+}
+
+func newTestOutbox(t *testing.T) (*outbox.Outbox, *sql.DB, *fakeOutboxDB) {
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestOutboxEntity](registry, "test.outbox")
+
+	db, fake := openFakeOutboxDB()
+	t.Cleanup(func() { _ = db.Close() })
+
+	box, err := outbox.New(db, registry, outbox.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, _ := db.Begin()
+	if _, err := box.Add(tx, TestOutboxEntity{Name: "rolled back"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = tx.Rollback()
+
+	tx, _ = db.Begin()
+	if _, err := box.Add(tx, TestOutboxEntity{Name: "committed"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := box.Add(tx, 1); err != errors.UnknownEventTypeErr {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = tx.Commit()
+
+	return box, db, fake
+}
+
+func TestOutboxRelay(t *testing.T) {
+	box, _, fake := newTestOutbox(t)
+	if fake.count(false) != 1 {
+		t.Fatalf("only the committed event must be written: %d", fake.count(false))
+	}
+
+	// the messages are kept until they can be triggered
+	eventx.Close()
+	relay := outbox.NewRelay(box, outbox.RelayOptions{})
+	if relayed, err := relay.RelayOnce(gocontext.Background()); relayed != 0 || err == nil {
+		t.Fatalf("unexpected relay: %d, %v", relayed, err)
+	}
+	if fake.count(false) != 1 {
+		t.Fatal("the message must stay pending")
+	}
+
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	received := make(chan entity.Envelope[TestOutboxEntity], 2)
+	_ = eventx.RegisterFuncEnvelopeAsEventListener(func(_ gocontext.Context, envelope entity.Envelope[TestOutboxEntity]) error {
+		received <- envelope
+		return nil
+	})
+
+	if relayed, err := relay.RelayOnce(gocontext.Background()); relayed != 1 || err != nil {
+		t.Fatalf("unexpected relay: %d, %v", relayed, err)
+	}
+	select {
+	case envelope := <-received:
+		if envelope.Entity.Name != "committed" || envelope.ID == "" {
+			t.Fatalf("unexpected envelope: %+v", envelope)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event was not relayed")
+	}
+	if fake.count(false) != 0 || fake.count(true) != 1 {
+		t.Fatal("the message must be marked dispatched")
+	}
+
+	if deleted, err := box.DeleteDispatched(gocontext.Background(), time.Now().Add(time.Second)); deleted != 1 || err != nil {
+		t.Fatalf("unexpected delete: %d, %v", deleted, err)
+	}
+}
+
+func TestOutboxRelayPolling(t *testing.T) {
+	box, db, fake := newTestOutbox(t)
+
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	received := make(chan TestOutboxEntity, 2)
+	_ = eventx.RegisterFuncAsEventListener(func(entity TestOutboxEntity) error {
+		received <- entity
+		return nil
+	})
+
+	relay := outbox.NewRelay(box, outbox.RelayOptions{Interval: 10 * time.Millisecond})
+	relay.Start()
+	defer relay.Stop()
+
+	tx, _ := db.Begin()
+	_, _ = box.Add(tx, TestOutboxEntity{Name: "later"})
+	_ = tx.Commit()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("event was not relayed")
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for fake.count(true) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("dispatched: %d", fake.count(true))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOutboxRelayUndecodable(t *testing.T) {
+	box, db, fake := newTestOutbox(t)
+
+	// written by a producer whose type is unknown to the relay
+	other := codec.NewRegistry(nil)
+	_ = codec.Register[TestOutboxEntity](other, "test.outbox.unknown")
+	producer, _ := outbox.New(db, other, outbox.Options{})
+	tx, _ := db.Begin()
+	_, _ = producer.Add(tx, TestOutboxEntity{Name: "unknown"})
+	_ = tx.Commit()
+
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+	_ = eventx.RegisterFuncAsEventListener(func(TestOutboxEntity) error { return nil })
+
+	var deadLetters []string
+	relay := outbox.NewRelay(box, outbox.RelayOptions{
+		BatchSize: 1,
+		DeadLetter: func(_ gocontext.Context, message outbox.Message, err error) error {
+			deadLetters = append(deadLetters, message.TypeName)
+			return nil
+		},
+	})
+	for i := 0; i < 2; i++ {
+		if relayed, err := relay.RelayOnce(gocontext.Background()); relayed != 1 || err != nil {
+			t.Fatalf("unexpected relay: %d, %v", relayed, err)
+		}
+	}
+
+	if len(deadLetters) != 1 || deadLetters[0] != "test.outbox.unknown/v1" || fake.count(false) != 0 {
+		t.Fatalf("dead letters: %v, pending: %d", deadLetters, fake.count(false))
+	}
+}
+
+type TestOutboxUnhandledEntity struct {
+	Name string
+}
+
+func TestOutboxRelayFailure(t *testing.T) {
+	_, db, fake := newTestOutbox(t)
+
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestOutboxEntity](registry, "test.outbox")
+	_ = codec.Register[TestOutboxUnhandledEntity](registry, "test.outbox.unhandled")
+	producer, _ := outbox.New(db, registry, outbox.Options{})
+	tx, _ := db.Begin()
+	_, _ = producer.Add(tx, TestOutboxUnhandledEntity{Name: "no listener"})
+	_, _ = producer.Add(tx, TestOutboxEntity{Name: "fail"})
+	_ = tx.Commit()
+
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	var failing atomic.Bool
+	failing.Store(true)
+	_ = eventx.RegisterFuncAsEventListener(func(e TestOutboxEntity) error {
+		if e.Name == "fail" && failing.Load() {
+			return stderrors.New("failed")
+		}
+		return nil
+	})
+
+	var deadLetters []string
+	relay := outbox.NewRelay(producer, outbox.RelayOptions{
+		DeadLetter: func(_ gocontext.Context, message outbox.Message, err error) error {
+			if !stderrors.Is(err, errors.NotFoundEventListenerErr) {
+				t.Errorf("unexpected dead letter: %v", err)
+			}
+			deadLetters = append(deadLetters, message.TypeName)
+			return nil
+		},
+	})
+
+	// the failed message stays pending
+	if relayed, err := relay.RelayOnce(gocontext.Background()); relayed != 2 || err == nil {
+		t.Fatalf("unexpected relay: %d, %v", relayed, err)
+	}
+	if len(deadLetters) != 1 || fake.count(false) != 1 {
+		t.Fatalf("dead letters: %v, pending: %d", deadLetters, fake.count(false))
+	}
+
+	failing.Store(false)
+	if relayed, err := relay.RelayOnce(gocontext.Background()); relayed != 1 || err != nil {
+		t.Fatalf("unexpected relay: %d, %v", relayed, err)
+	}
+	if fake.count(false) != 0 {
+		t.Fatalf("pending: %d", fake.count(false))
+	}
+}
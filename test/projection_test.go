@@ -0,0 +1,110 @@
+package test
+
+import (
+	gocontext "context"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/eventstore"
+	"github.com/aivyss/eventx/projection"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testBalances struct {
+	lock     sync.Mutex
+	balances map[string]int
+	applied  int
+}
+
+func (b *testBalances) get(streamID string) (int, int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.balances[streamID], b.applied
+}
+
+func newTestBalanceProjection(balances *testBalances) *projection.Projection {
+	p := projection.New("balances")
+	projection.Handle(p, func(_ gocontext.Context, e TestStoreDeposited, record eventstore.RecordedEvent) error {
+		balances.lock.Lock()
+		defer balances.lock.Unlock()
+
+		balances.balances[record.StreamID] += e.Amount
+		balances.applied += 1
+		return nil
+	})
+	p.OnReset(func(gocontext.Context) error {
+		balances.lock.Lock()
+		defer balances.lock.Unlock()
+
+		balances.balances = map[string]int{}
+		return nil
+	})
+
+	return p
+}
+
+func TestProjectionRunner(t *testing.T) {
+	ctx := gocontext.Background()
+	noPublish := eventstore.Options{
+		Publish: func(gocontext.Context, any, entity.Metadata) ([]entity.EventContext, error) { return nil, nil },
+	}
+	store, _ := eventstore.New(eventstore.NewMemoryBackend(), newTestStoreRegistry(), noPublish)
+	_, _ = store.Append(ctx, "a", eventstore.NoStream, TestStoreOpened{}, TestStoreDeposited{Amount: 1}, TestStoreDeposited{Amount: 2})
+	_, _ = store.Append(ctx, "b", eventstore.NoStream, TestStoreDeposited{Amount: 5})
+
+	checkpoints, err := projection.NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// catch-up
+	balances := &testBalances{balances: map[string]int{}}
+	runner, _ := projection.NewRunner(newTestBalanceProjection(balances), store, checkpoints, projection.RunnerOptions{BatchSize: 2})
+	if position, err := runner.CatchUp(ctx); position != 4 || err != nil {
+		t.Fatalf("unexpected catch-up: %d, %v", position, err)
+	}
+	if balance, _ := balances.get("a"); balance != 3 {
+		t.Fatalf("balance: %d", balance)
+	}
+
+	// a restarted runner resumes from the checkpoint
+	_, _ = store.Append(ctx, "a", eventstore.AnyVersion, TestStoreDeposited{Amount: 10})
+	runner, _ = projection.NewRunner(newTestBalanceProjection(balances), store, checkpoints, projection.RunnerOptions{PollInterval: 5 * time.Millisecond})
+
+	runContext, cancel := gocontext.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(runContext) }()
+
+	// live tailing
+	_, _ = store.Append(ctx, "b", eventstore.AnyVersion, TestStoreDeposited{Amount: 20})
+	deadline := time.Now().Add(time.Second)
+	for runner.Position() != 6 {
+		if time.Now().After(deadline) {
+			t.Fatalf("position: %d", runner.Position())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	a, applied := balances.get("a")
+	b, _ := balances.get("b")
+	if a != 13 || b != 25 || applied != 5 {
+		t.Fatalf("a: %d, b: %d, applied: %d", a, b, applied)
+	}
+	if position, _ := checkpoints.Load(ctx, "balances"); position != 6 {
+		t.Fatalf("checkpoint: %d", position)
+	}
+
+	// rebuild from zero
+	if position, err := runner.Rebuild(ctx); position != 6 || err != nil {
+		t.Fatalf("unexpected rebuild: %d, %v", position, err)
+	}
+	a, applied = balances.get("a")
+	if a != 13 || applied != 10 {
+		t.Fatalf("a: %d, applied: %d", a, applied)
+	}
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestRateLimitedWait struct{}
+
+type TestRateLimitedUnrelated struct{}
+
+type TestRateLimitedA struct{}
+
+type TestRateLimitedB struct{}
+
+type TestRateLimitedMaxWait struct{}
+
+type TestRateLimitedDeadLetter struct {
+	Value int
+}
+
+func TestRateLimitWait(t *testing.T) {
+	// two workers, which must not be held by the waiting events
+	eventx.RunApplication(10, 2, true)
+	defer eventx.Close()
+
+	var triggered atomic.Int32
+	limiter := entity.NewRateLimiter(20, 2)
+	listener := entity.BuildEventListener(func(TestRateLimitedWait) error {
+		triggered.Add(1)
+		return nil
+	})
+	if err := eventx.RegisterEventListener(listener, entity.RateLimit(limiter, entity.RateLimitOptions[TestRateLimitedWait]{})); err != nil {
+		t.Fatal(err)
+	}
+	_ = eventx.RegisterFuncAsEventListener(func(TestRateLimitedUnrelated) error { return nil })
+
+	start := time.Now()
+	var ctxs []entity.EventContext
+	for i := 0; i < 6; i++ {
+		triggered, err := eventx.Trigger(TestRateLimitedWait{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctxs = append(ctxs, triggered...)
+	}
+
+	unrelated, err := eventx.Trigger(TestRateLimitedUnrelated{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFinished(t, unrelated)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("the waiting events have held the workers: %s", elapsed)
+	}
+
+	waitFinished(t, ctxs)
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond || triggered.Load() != 6 {
+		t.Fatalf("elapsed: %s, triggered: %d", elapsed, triggered.Load())
+	}
+	for _, ctx := range ctxs {
+		if ctx.Err() != nil {
+			t.Fatal(ctx.Err())
+		}
+	}
+}
+
+func TestRateLimitSharedReject(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	limiter := entity.NewRateLimiter(1, 2)
+	var caught atomic.Int32
+	a := entity.BuildCatchErrEventListener(func(TestRateLimitedA) error { return nil }, func(error) { caught.Add(1) })
+	b := entity.BuildCatchErrEventListener(func(TestRateLimitedB) error { return nil }, func(err error) {
+		if stderrors.Is(err, errors.RateLimitedErr) {
+			caught.Add(1)
+		}
+	})
+	_ = eventx.RegisterEventListener(a, entity.RateLimit(limiter, entity.RateLimitOptions[TestRateLimitedA]{Policy: entity.RateLimitReject}))
+	_ = eventx.RegisterEventListener(b, entity.RateLimit(limiter, entity.RateLimitOptions[TestRateLimitedB]{Policy: entity.RateLimitReject}))
+
+	first, _ := eventx.Trigger(TestRateLimitedA{})
+	second, _ := eventx.Trigger(TestRateLimitedA{})
+	waitFinished(t, append(first, second...))
+	third, _ := eventx.Trigger(TestRateLimitedB{})
+	waitFinished(t, third)
+
+	if first[0].Err() != nil || second[0].Err() != nil || third[0].Err() != errors.RateLimitedErr {
+		t.Fatalf("unexpected errors: %v, %v, %v", first[0].Err(), second[0].Err(), third[0].Err())
+	}
+	if caught.Load() != 1 {
+		t.Fatalf("unexpected catches: %d", caught.Load())
+	}
+}
+
+func TestRateLimitDeadLetter(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	var triggered atomic.Int32
+	deadLetters := make(chan int, 10)
+	listener := entity.BuildEventListener(func(TestRateLimitedDeadLetter) error {
+		triggered.Add(1)
+		return nil
+	})
+	err := eventx.RegisterEventListener(listener, entity.RateLimit(entity.NewRateLimiter(1, 1), entity.RateLimitOptions[TestRateLimitedDeadLetter]{
+		Policy: entity.RateLimitDeadLetter,
+		DeadLetter: func(_ gocontext.Context, envelope entity.Envelope[TestRateLimitedDeadLetter]) error {
+			if envelope.ID == "" {
+				return stderrors.New("no metadata")
+			}
+			deadLetters <- envelope.Entity.Value
+			return nil
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ctxs []entity.EventContext
+	for i := 0; i < 3; i++ {
+		triggered, _ := eventx.Trigger(TestRateLimitedDeadLetter{Value: i})
+		ctxs = append(ctxs, triggered...)
+	}
+	waitFinished(t, ctxs)
+
+	rejected := 0
+	for _, ctx := range ctxs {
+		if ctx.Err() == errors.RateLimitedErr {
+			rejected += 1
+		} else if ctx.Err() != nil {
+			t.Fatal(ctx.Err())
+		}
+	}
+	if triggered.Load() != 1 || rejected != 2 || len(deadLetters) != 2 {
+		t.Fatalf("triggered: %d, rejected: %d, dead letters: %d", triggered.Load(), rejected, len(deadLetters))
+	}
+}
+
+func TestRateLimitMaxWait(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	listener := entity.BuildEventListener(func(TestRateLimitedMaxWait) error { return nil })
+	err := eventx.RegisterEventListener(listener, entity.RateLimit(entity.NewRateLimiter(1, 1), entity.RateLimitOptions[TestRateLimitedMaxWait]{
+		MaxWait: 100 * time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := eventx.Trigger(TestRateLimitedMaxWait{})
+	second, _ := eventx.Trigger(TestRateLimitedMaxWait{})
+	waitFinished(t, append(first, second...))
+
+	// the event executed last would wait a second for its token
+	errs := []error{first[0].Err(), second[0].Err()}
+	if !(errs[0] == nil && errs[1] == errors.RateLimitedErr) && !(errs[0] == errors.RateLimitedErr && errs[1] == nil) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
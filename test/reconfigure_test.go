@@ -0,0 +1,155 @@
+package test
+
+import (
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type TestReconfigureEntity int
+
+func TestReconfigure(t *testing.T) {
+	t.Run("keeps listeners and queued events", func(t *testing.T) {
+		var mutex sync.Mutex
+		count := 0
+		loopCnt := 300
+
+		eventx.RunDefaultApplication()
+		defer eventx.Close()
+
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestReconfigureEntity) error {
+			time.Sleep(time.Millisecond)
+			mutex.Lock()
+			count += 1
+			mutex.Unlock()
+
+			return nil
+		})
+
+		go func() {
+			for i := 0; i < loopCnt; i++ {
+				_, _ = eventx.Trigger(TestReconfigureEntity(i))
+			}
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		if err := eventx.Reconfigure(20, 30, 5); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if err := eventx.Reconfigure(2, 3, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			mutex.Lock()
+			finished := count == loopCnt
+			mutex.Unlock()
+
+			if finished {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d processed events, got %d", loopCnt, count)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("scales workers", func(t *testing.T) {
+		eventx.RunApplication(5, 10, true)
+		defer eventx.Close()
+		time.Sleep(100 * time.Millisecond)
+		before := runtime.NumGoroutine()
+
+		if err := eventx.Reconfigure(5, 5, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for before-10 != runtime.NumGoroutine() {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d goroutines, got %d", before-10, runtime.NumGoroutine())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("keeps the order of queued events", func(t *testing.T) {
+		eventx.RunApplication(10, 1, false)
+		defer eventx.Close()
+		// a single dispense worker and a single event worker process the events in order
+		if err := eventx.Reconfigure(10, 1, 1); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		var mutex sync.Mutex
+		var order []TestReconfigureEntity
+		release := make(chan struct{})
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestReconfigureEntity) error {
+			if entity == 0 {
+				<-release
+			}
+			mutex.Lock()
+			order = append(order, entity)
+			mutex.Unlock()
+
+			return nil
+		})
+
+		loopCnt := 15
+		for i := 0; i < 10; i++ {
+			_, _ = eventx.Trigger(TestReconfigureEntity(i))
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		// the queued events do not fit into the new buffer until the worker is released
+		if err := eventx.Reconfigure(2, 1, 1); err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for i := 10; i < loopCnt; i++ {
+				_, _ = eventx.Trigger(TestReconfigureEntity(i))
+			}
+		}()
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			mutex.Lock()
+			finished := len(order) == loopCnt
+			mutex.Unlock()
+
+			if finished {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d processed events, got %d", loopCnt, len(order))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		for i, entity := range order {
+			if entity != TestReconfigureEntity(i) {
+				t.Fatalf("events out of order: %v", order)
+			}
+		}
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		eventx.RunDefaultApplication()
+		defer eventx.Close()
+
+		if err := eventx.Reconfigure(5, 0, 3); err != errors.InvalidConfigurationErr {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"testing"
+	"time"
+)
+
+type TestRequestQuote struct {
+	Symbol string
+}
+
+type TestRequestPrice struct {
+	Symbol string
+	Price  int
+}
+
+type TestRequestSlowQuery struct{}
+
+type TestRequestUnansweredQuery struct{}
+
+type TestRequestCanceledQuery struct{}
+
+type TestRequestBlocker struct{}
+
+func TestRequestReply(t *testing.T) {
+	// registered before the application runs
+	err := eventx.RegisterFuncAsResponder(func(_ gocontext.Context, q TestRequestQuote) (TestRequestPrice, error) {
+		if q.Symbol == "" {
+			return TestRequestPrice{}, stderrors.New("no symbol")
+		}
+		return TestRequestPrice{Symbol: q.Symbol, Price: len(q.Symbol)}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	// another listener of the query type is not a responder
+	_ = eventx.RegisterFuncAsEventListener(func(TestRequestQuote) error { return nil })
+	err = eventx.RegisterFuncAsResponder(func(_ gocontext.Context, q TestRequestQuote) (TestRequestPrice, error) {
+		return TestRequestPrice{}, nil
+	})
+	if !stderrors.Is(err, errors.AlreadyRegisteredErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Second)
+	defer cancel()
+
+	price, err := eventx.Request[TestRequestQuote, TestRequestPrice](ctx, nil, TestRequestQuote{Symbol: "abc"})
+	if err != nil || price != (TestRequestPrice{Symbol: "abc", Price: 3}) {
+		t.Fatalf("unexpected reply: %+v, %v", price, err)
+	}
+
+	if _, err := eventx.Request[TestRequestQuote, TestRequestPrice](ctx, nil, TestRequestQuote{}); err == nil || err.Error() != "no symbol" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := eventx.Request[TestRequestQuote, string](ctx, nil, TestRequestQuote{Symbol: "a"}); !stderrors.Is(err, errors.InvalidConfigurationErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := eventx.Request[TestRequestUnansweredQuery, string](ctx, nil, TestRequestUnansweredQuery{}); !stderrors.Is(err, errors.NotFoundEventListenerErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRequestReplyTimeout(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	canceled := make(chan struct{})
+	_ = eventx.RegisterFuncAsResponder(func(ctx gocontext.Context, _ TestRequestSlowQuery) (int, error) {
+		<-ctx.Done()
+		close(canceled)
+		return 0, ctx.Err()
+	})
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := eventx.Request[TestRequestSlowQuery, int](ctx, nil, TestRequestSlowQuery{}); !stderrors.Is(err, gocontext.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the context of the responder was not canceled")
+	}
+}
+
+func TestRequestReplyCanceled(t *testing.T) {
+	// a single worker and a single slot, so that the query waits in the dispense queue behind the blockers
+	eventx.RunApplication(1, 1, true)
+	defer eventx.Close()
+
+	release := make(chan struct{})
+	_ = eventx.RegisterFuncAsEventListener(func(TestRequestBlocker) error {
+		<-release
+		return nil
+	})
+	_ = eventx.RegisterFuncAsResponder(func(gocontext.Context, TestRequestCanceledQuery) (int, error) {
+		return 1, nil
+	})
+	for i := 0; i < 5; i++ {
+		_, _ = eventx.Trigger(TestRequestBlocker{})
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// cancels the query before its execution
+	bus := eventx.BusFunc(func(ctx gocontext.Context, event any) error {
+		ctxs, err := eventx.TriggerAny(ctx, event, entity.Metadata{})
+		for _, c := range ctxs {
+			c.Cancel()
+		}
+		return err
+	})
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := eventx.Request[TestRequestCanceledQuery, int](ctx, bus, TestRequestCanceledQuery{}); !stderrors.Is(err, errors.NoReplyErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package test
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/saga"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type TestSagaOrderPlaced struct {
+	OrderID string
+	Fail    bool
+	Stall   bool
+}
+
+type TestSagaStockReserved struct {
+	OrderID string
+}
+
+type TestSagaCardCharged struct {
+	OrderID string
+}
+
+type TestSagaState struct {
+	OrderID  string
+	Fail     bool
+	Reserved bool
+	Charged  bool
+	Shipped  bool
+}
+
+type testSagaLog struct {
+	lock    sync.Mutex
+	entries []string
+}
+
+func (l *testSagaLog) add(entry string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *testSagaLog) get() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]string(nil), l.entries...)
+}
+
+func newTestSaga(t *testing.T, store saga.Store, compensations *testSagaLog) *saga.Saga[TestSagaState] {
+	s := saga.New[TestSagaState]("order-fulfilment", saga.Options{Store: store, Interval: 10 * time.Millisecond})
+	saga.AddStep(s, saga.Step[TestSagaOrderPlaced, TestSagaState]{
+		Name: "reserve",
+		Action: func(ctx gocontext.Context, e TestSagaOrderPlaced, state *TestSagaState) error {
+			state.OrderID, state.Fail, state.Reserved = e.OrderID, e.Fail, true
+			if e.Stall {
+				return nil
+			}
+			_, err := eventx.TriggerAny(ctx, TestSagaStockReserved{OrderID: e.OrderID}, entity.Metadata{})
+			return err
+		},
+		Compensate: func(_ gocontext.Context, state *TestSagaState) error {
+			compensations.add(state.OrderID + ":reserve")
+			return nil
+		},
+	})
+	saga.AddStep(s, saga.Step[TestSagaStockReserved, TestSagaState]{
+		Name: "charge",
+		Action: func(ctx gocontext.Context, e TestSagaStockReserved, state *TestSagaState) error {
+			state.Charged = true
+			_, err := eventx.TriggerAny(ctx, TestSagaCardCharged{OrderID: e.OrderID}, entity.Metadata{})
+			return err
+		},
+		Compensate: func(_ gocontext.Context, state *TestSagaState) error {
+			compensations.add(state.OrderID + ":charge")
+			return nil
+		},
+		Timeout: 50 * time.Millisecond,
+	})
+	saga.AddStep(s, saga.Step[TestSagaCardCharged, TestSagaState]{
+		Name: "ship",
+		Action: func(_ gocontext.Context, _ TestSagaCardCharged, state *TestSagaState) error {
+			if state.Fail {
+				return stderrors.New("no carrier")
+			}
+			state.Shipped = true
+			return nil
+		},
+	})
+	if err := s.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func waitSagaInstance(t *testing.T, s *saga.Saga[TestSagaState], correlationID string) saga.Instance {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		instance, found, err := s.Instance(gocontext.Background(), correlationID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found && instance.Status != saga.Running {
+			return instance
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("saga instance has not finished")
+	return saga.Instance{}
+}
+
+func TestSaga(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	compensations := &testSagaLog{}
+	s := newTestSaga(t, saga.NewMemoryStore(), compensations)
+	s.Start()
+	defer s.Stop()
+
+	for _, c := range []struct {
+		order         TestSagaOrderPlaced
+		status        saga.Status
+		compensations []string
+	}{
+		{TestSagaOrderPlaced{OrderID: "ok"}, saga.Completed, nil},
+		{TestSagaOrderPlaced{OrderID: "fail", Fail: true}, saga.Compensated, []string{"fail:charge", "fail:reserve"}},
+		{TestSagaOrderPlaced{OrderID: "stall", Stall: true}, saga.Compensated, []string{"stall:reserve"}},
+	} {
+		ctxs, err := eventx.Trigger(c.order)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		instance := waitSagaInstance(t, s, ctxs[0].EventID())
+		if instance.Status != c.status {
+			t.Fatalf("%s: status %s, error %q", c.order.OrderID, instance.Status, instance.Error)
+		}
+
+		var got []string
+		for _, entry := range compensations.get() {
+			if strings.HasPrefix(entry, c.order.OrderID+":") {
+				got = append(got, entry)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(c.compensations, ",") {
+			t.Fatalf("%s: compensations %v", c.order.OrderID, got)
+		}
+
+		state, err := s.State(instance)
+		if err != nil || state.OrderID != c.order.OrderID || state.Shipped != (c.status == saga.Completed) {
+			t.Fatalf("%s: unexpected state: %+v", c.order.OrderID, state)
+		}
+		if c.order.Stall && !strings.Contains(instance.Error, "StepTimeout") {
+			t.Fatalf("unexpected error: %q", instance.Error)
+		}
+	}
+
+	// an event which is not expected by any instance is skipped
+	ctxs, err := eventx.Trigger(TestSagaCardCharged{OrderID: "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFinished(t, ctxs)
+	if !ctxs[0].IsSkipped() {
+		t.Fatal("an unexpected event must be skipped")
+	}
+}
+
+func TestSagaFileStore(t *testing.T) {
+	store, err := saga.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := gocontext.Background()
+	instances := []saga.Instance{
+		{Saga: "s", CorrelationID: "a/1", Step: 1, Status: saga.Running, State: []byte(`{"OrderID":"a"}`)},
+		{Saga: "s", CorrelationID: "b", Status: saga.Completed},
+	}
+	for _, instance := range instances {
+		if err := store.Save(ctx, instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	instance, found, err := store.Load(ctx, "s", "a/1")
+	if err != nil || !found || instance.Step != 1 || string(instance.State) != `{"OrderID":"a"}` {
+		t.Fatalf("unexpected instance: %+v, %v", instance, err)
+	}
+	if _, found, _ := store.Load(ctx, "s", "c"); found {
+		t.Fatal("no instance must be found")
+	}
+
+	running, err := store.Running(ctx, "s")
+	if err != nil || len(running) != 1 || running[0].CorrelationID != "a/1" {
+		t.Fatalf("unexpected running instances: %+v, %v", running, err)
+	}
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"errors"
+	"github.com/aivyss/eventx"
+	"testing"
+	"time"
+)
+
+type TestStatsEntity int
+
+func TestStats(t *testing.T) {
+	t.Run("counts events per listener", func(t *testing.T) {
+		eventx.RunDefaultApplication()
+		defer eventx.Close()
+
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestStatsEntity) error {
+			return nil
+		})
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestStatsEntity) error {
+			if entity%2 == 0 {
+				return errors.New("even")
+			}
+			return nil
+		})
+
+		loopCnt := 10
+		for i := 0; i < loopCnt; i++ {
+			_, _ = eventx.Trigger(TestStatsEntity(i))
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			stats := eventx.Stats()
+			counters := stats.EventTypes["test.TestStatsEntity"]
+			if counters.Succeeded+counters.Failed == uint64(loopCnt*2) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("unexpected counters: %+v", counters)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		stats := eventx.Stats()
+		if len(stats.Listeners) != 2 {
+			t.Fatalf("unexpected listeners: %+v", stats.Listeners)
+		}
+
+		first, second := stats.Listeners[0], stats.Listeners[1]
+		if first.ID != "test.TestStatsEntity#0" || first.Triggered != uint64(loopCnt) || first.Succeeded != uint64(loopCnt) {
+			t.Fatalf("unexpected listener stats: %+v", first.EventCounters)
+		}
+		if second.Failed != uint64(loopCnt/2) || second.Succeeded != uint64(loopCnt/2) {
+			t.Fatalf("unexpected listener stats: %+v", second.EventCounters)
+		}
+		if stats.Execution.Count != uint64(loopCnt*2) || stats.QueueWait.Count != uint64(loopCnt*2) {
+			t.Fatalf("unexpected histograms: %+v, %+v", stats.Execution, stats.QueueWait)
+		}
+		if stats.Workers.Process.Size != 10 || stats.Queues.Process.Cap != 5 {
+			t.Fatalf("unexpected pool stats: %+v, %+v", stats.Workers, stats.Queues)
+		}
+	})
+
+	t.Run("counts canceled events", func(t *testing.T) {
+		eventx.RunApplication(0, 1, true)
+		defer eventx.Close()
+
+		block := make(chan struct{})
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestStatsEntity) error {
+			<-block
+			return nil
+		})
+
+		// 1 running + 3 held by the dispense pool + 1 in the dispense channel
+		for i := 0; i < 4; i++ {
+			_, _ = eventx.Trigger(TestStatsEntity(i))
+			time.Sleep(10 * time.Millisecond)
+		}
+		eventCtxs, _ := eventx.Trigger(TestStatsEntity(4))
+		eventCtxs[0].Cancel()
+		close(block)
+		eventx.Close()
+
+		counters := eventx.Stats().Events
+		if counters.Triggered != 5 || counters.Canceled != 1 || counters.Succeeded != 4 {
+			t.Fatalf("unexpected counters: %+v", counters)
+		}
+	})
+}
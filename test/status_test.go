@@ -0,0 +1,214 @@
+package test
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/context"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type TestStatusEntity int
+
+type TestStatusDrainEntity int
+
+func TestStatus(t *testing.T) {
+	t.Run("not started context rejects events", func(t *testing.T) {
+		ctx := context.NewApplicationContext(1, 1, true)
+		listener := entity.BuildEventListener(func(entity TestStatusEntity) error {
+			return nil
+		})
+
+		if ctx.Status() != context.NotStarted {
+			t.Fatalf("unexpected status: %s", ctx.Status())
+		}
+		if err := ctx.QueueEventSet(entity.NewEventSet(listener, TestStatusEntity(1))); err != errors.ApplicationNotRunningErr {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx.Close()
+		if ctx.Status() != context.Closed {
+			t.Fatalf("unexpected status: %s", ctx.Status())
+		}
+	})
+
+	t.Run("trigger after close", func(t *testing.T) {
+		eventx.RunDefaultApplication()
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestStatusEntity) error {
+			return nil
+		})
+		if eventx.Status() != context.Running {
+			t.Fatalf("unexpected status: %s", eventx.Status())
+		}
+
+		eventx.Close()
+		if eventx.Status() != context.Closed {
+			t.Fatalf("unexpected status: %s", eventx.Status())
+		}
+
+		done := make(chan error)
+		go func() {
+			_, err := eventx.Trigger(TestStatusEntity(1))
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err != errors.ApplicationClosedErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("trigger is blocked after close")
+		}
+	})
+
+	t.Run("close drains queued events", func(t *testing.T) {
+		var mutex sync.Mutex
+		count := 0
+		loopCnt := 100
+
+		eventx.RunDefaultApplication()
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestStatusEntity) error {
+			time.Sleep(time.Millisecond)
+			mutex.Lock()
+			count += 1
+			mutex.Unlock()
+
+			return nil
+		})
+
+		for i := 0; i < loopCnt; i++ {
+			_, _ = eventx.Trigger(TestStatusEntity(i))
+		}
+		eventx.Close()
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if count != loopCnt {
+			t.Fatalf("expected %d processed events, got %d", loopCnt, count)
+		}
+	})
+
+	t.Run("registration before run is buffered", func(t *testing.T) {
+		received := make(chan TestStatusEntity, 1)
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestStatusEntity) error {
+			received <- entity
+			return nil
+		})
+
+		eventx.RunDefaultApplication()
+		defer eventx.Close()
+
+		if _, err := eventx.Trigger(TestStatusEntity(7)); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case e := <-received:
+			if e != 7 {
+				t.Fatalf("unexpected entity: %d", e)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("buffered listener was not registered")
+		}
+	})
+	t.Run("buffered duplicate is reported", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_ = eventx.RegisterFuncAsEventListener(func(entity TestStatusEntity) error {
+				return nil
+			})
+		}
+
+		err := eventx.RunApplication(context.DefaultEventChannelBufferSize, context.DefaultEventProcessPoolSize, false)
+		defer eventx.Close()
+		if !stderrors.Is(err, errors.AlreadyRegisteredErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("close from a listener is bounded", func(t *testing.T) {
+		eventx.RunDefaultApplication()
+		defer eventx.Close()
+
+		closed := make(chan error, 1)
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestStatusEntity) error {
+			ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+			defer cancel()
+			// the event of the listener is still in flight
+			closed <- eventx.CloseContext(ctx)
+			return nil
+		})
+		_, _ = eventx.Trigger(TestStatusEntity(1))
+
+		select {
+		case err := <-closed:
+			if err != gocontext.DeadlineExceeded || eventx.Status() != context.Closed {
+				t.Fatalf("unexpected close: %v, %s", err, eventx.Status())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("close is blocked by the listener calling it")
+		}
+	})
+	t.Run("close and registration while draining", func(t *testing.T) {
+		eventx.RunDefaultApplication()
+		defer eventx.Close()
+
+		release := make(chan struct{})
+		_ = eventx.RegisterFuncAsEventListener(func(entity TestStatusEntity) error {
+			<-release
+			return nil
+		})
+		_, _ = eventx.Trigger(TestStatusEntity(1))
+
+		go eventx.Close()
+		deadline := time.Now().Add(time.Second)
+		for eventx.Status() != context.Draining {
+			if time.Now().After(deadline) {
+				t.Fatal("the application is not draining")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		// buffered for the next application
+		received := make(chan TestStatusDrainEntity, 1)
+		if err := eventx.RegisterFuncAsEventListener(func(entity TestStatusDrainEntity) error {
+			received <- entity
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := eventx.CloseContext(ctx); err != gocontext.DeadlineExceeded {
+			t.Fatalf("the second close must wait for the drain: %v", err)
+		}
+
+		closed := make(chan error, 1)
+		go func() {
+			closed <- eventx.CloseContext(gocontext.Background())
+		}()
+		close(release)
+		select {
+		case err := <-closed:
+			if err != nil || eventx.Status() != context.Closed {
+				t.Fatalf("unexpected close: %v, %s", err, eventx.Status())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the second close was not released by the drain")
+		}
+
+		eventx.RunDefaultApplication()
+		if _, err := eventx.Trigger(TestStatusDrainEntity(3)); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("the listener registered while draining was not registered")
+		}
+	})
+}
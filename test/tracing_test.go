@@ -0,0 +1,64 @@
+package test
+
+import (
+	gocontext "context"
+	"errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/context"
+	"github.com/aivyss/eventx/tracing"
+	"testing"
+	"time"
+)
+
+type TestTracingEntity int
+type TestTracingChainedEntity int
+
+func TestTracing(t *testing.T) {
+	tracer := tracing.NewInMemoryTracer()
+	eventx.SetTracer(tracer)
+	defer eventx.SetTracer(nil)
+
+	eventx.RunDefaultApplication()
+
+	_ = eventx.RegisterFuncContextAsEventListener(func(ctx gocontext.Context, entity TestTracingEntity) error {
+		_, err := eventx.TriggerContext(ctx, TestTracingChainedEntity(entity))
+		return err
+	})
+	_ = eventx.RegisterFuncAsEventListener(func(entity TestTracingChainedEntity) error {
+		return errors.New("chained failure")
+	})
+
+	requestCtx, endRequest := tracer.StartSpan(gocontext.Background(), "http request")
+	_, _ = eventx.TriggerContext(requestCtx, TestTracingEntity(1))
+	endRequest(nil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for eventx.Stats().Events.Failed != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("events were not processed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	eventx.Close()
+
+	spans := tracer.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+
+	request, listener, chained := spans[0], spans[1], spans[2]
+	if len(listener.Links) != 1 || listener.Links[0] != request.SpanContext {
+		t.Fatalf("listener span is not linked to the request span: %+v", listener)
+	}
+	if len(chained.Links) != 1 || chained.Links[0] != listener.SpanContext {
+		t.Fatalf("chained span is not linked to the listener span: %+v", chained)
+	}
+	if listener.Attributes["eventx.listener"] != "test.TestTracingEntity#0" ||
+		listener.Attributes["eventx.attempt"] != 1 ||
+		listener.Outcome != context.SpanOutcomeSucceeded {
+		t.Fatalf("unexpected listener span: %+v", listener)
+	}
+	if !chained.Ended || chained.Outcome != context.SpanOutcomeFailed || chained.Err == nil {
+		t.Fatalf("unexpected chained span: %+v", chained)
+	}
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	gocontext "context"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestUpcastCustomer is the third version of the schema:
+// v1 {"Name"}, v2 {"FullName"}, v3 {"FullName", "Country"}
+type TestUpcastCustomer struct {
+	FullName string
+	Country  string
+}
+
+func (TestUpcastCustomer) SchemaVersion() int {
+	return 3
+}
+
+func newTestUpcastRegistry(t *testing.T) *codec.Registry {
+	registry := codec.NewRegistry(nil)
+	if err := codec.Register[TestUpcastCustomer](registry, "test.customer"); err != nil {
+		t.Fatal(err)
+	}
+	_ = registry.RegisterUpcaster("test.customer", 1, codec.UpcastJSON(func(object map[string]any) error {
+		object["FullName"] = object["Name"]
+		delete(object, "Name")
+		return nil
+	}))
+	_ = registry.RegisterUpcaster("test.customer", 2, codec.UpcastJSON(func(object map[string]any) error {
+		object["Country"] = "KR"
+		return nil
+	}))
+
+	return registry
+}
+
+func TestUpcast(t *testing.T) {
+	registry := newTestUpcastRegistry(t)
+	if version, ok := registry.CurrentVersion("test.customer"); !ok || version != 3 {
+		t.Fatalf("current version: %d", version)
+	}
+
+	wireName, _, _ := registry.Encode(TestUpcastCustomer{})
+	if wireName != "test.customer/v3" {
+		t.Fatalf("wire name: %s", wireName)
+	}
+
+	for _, c := range []struct {
+		wireName string
+		payload  string
+	}{
+		{"test.customer/v1", `{"Name":"a"}`},
+		{"test.customer/v2", `{"FullName":"a"}`},
+		{"test.customer/v3", `{"FullName":"a","Country":"KR"}`},
+	} {
+		event, err := registry.Decode(c.wireName, []byte(c.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event != (TestUpcastCustomer{FullName: "a", Country: "KR"}) {
+			t.Fatalf("%s: unexpected event: %+v", c.wireName, event)
+		}
+
+		info, ok := registry.DecodedType(c.wireName)
+		if !ok || info.Type != reflect.TypeOf(TestUpcastCustomer{}) {
+			t.Fatalf("%s: unexpected decoded type", c.wireName)
+		}
+	}
+
+	if err := registry.RegisterUpcaster("test.customer", 1, codec.UpcastJSON(nil)); err == nil {
+		t.Fatal("an upcaster must be registered once per version")
+	}
+}
+
+func TestUpcastPointerType(t *testing.T) {
+	registry := codec.NewRegistry(nil)
+	// the value receiver of SchemaVersion must not be called on a nil pointer
+	if err := codec.Register[*TestUpcastCustomer](registry, "test.customer"); err != nil {
+		t.Fatal(err)
+	}
+	if version, ok := registry.CurrentVersion("test.customer"); !ok || version != 3 {
+		t.Fatalf("current version: %d", version)
+	}
+}
+
+func TestUpcastDispatch(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	received := make(chan TestUpcastCustomer, 1)
+	_ = eventx.RegisterFuncAsEventListener(func(e TestUpcastCustomer) error {
+		received <- e
+		return nil
+	})
+
+	_, err := eventx.TriggerEncoded(gocontext.Background(), newTestUpcastRegistry(t), "test.customer/v1", []byte(`{"Name":"b"}`), entity.Metadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-received:
+		if e.FullName != "b" || e.Country != "KR" {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event was not dispatched")
+	}
+}
@@ -0,0 +1,281 @@
+package test
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/codec"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/wal"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestWALEntity struct {
+	Name string
+}
+
+func newTestWALCodec() *codec.Registry {
+	registry := codec.NewRegistry(nil)
+	_ = codec.Register[TestWALEntity](registry, "test.wal")
+
+	return registry
+}
+
+func waitIncomplete(t *testing.T, log *wal.Log, expected int) {
+	deadline := time.Now().Add(time.Second)
+	for len(log.Incomplete()) != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("incomplete entries: %d, expected: %d", len(log.Incomplete()), expected)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWALReplay(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir, wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first process fails to handle the events
+	eventx.RunApplication(5, 1, true)
+	_ = eventx.RegisterFuncAsEventListener(func(entity TestWALEntity) error {
+		if entity.Name == "ok" {
+			return nil
+		}
+		return stderrors.New("failed")
+	})
+	if err := eventx.EnableWAL(log); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, name := range []string{"a", "ok", "b"} {
+		ctxs, err := eventx.Trigger(TestWALEntity{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, ctxs[0].EventID())
+	}
+	waitIncomplete(t, log, 2)
+	eventx.Close()
+	eventx.DisableWAL()
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next process replays them
+	log, err = wal.Open(dir, wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	incomplete := log.Incomplete()
+	if len(incomplete) != 2 || incomplete[0].Metadata.ID != ids[0] || incomplete[1].Metadata.ID != ids[2] {
+		t.Fatalf("unexpected incomplete entries: %+v", incomplete)
+	}
+
+	eventx.RunApplication(5, 1, true)
+	defer eventx.Close()
+	defer eventx.DisableWAL()
+
+	replayed := make(chan entity.Envelope[TestWALEntity], 2)
+	_ = eventx.RegisterFuncEnvelopeAsEventListener(func(_ gocontext.Context, envelope entity.Envelope[TestWALEntity]) error {
+		replayed <- envelope
+		return nil
+	})
+	if err := eventx.EnableWAL(log); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{ids[0], ids[2]} {
+		select {
+		case envelope := <-replayed:
+			if envelope.ID != id {
+				t.Fatalf("replayed %s, expected %s", envelope.ID, id)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("event was not replayed")
+		}
+	}
+	waitIncomplete(t, log, 0)
+}
+
+func TestWALEnableTwice(t *testing.T) {
+	log, err := wal.Open(t.TempDir(), wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	if _, err := log.Append(TestWALEntity{Name: "a"}, entity.Metadata{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+	defer eventx.DisableWAL()
+
+	var triggered atomic.Int32
+	release := make(chan struct{})
+	_ = eventx.RegisterFuncAsEventListener(func(entity TestWALEntity) error {
+		triggered.Add(1)
+		<-release
+		return nil
+	})
+
+	if err := eventx.EnableWAL(log); err != nil {
+		t.Fatal(err)
+	}
+	// the entry being replayed is not replayed again
+	if err := eventx.EnableWAL(log); err != nil {
+		t.Fatal(err)
+	}
+	eventx.DisableWAL()
+	if err := eventx.EnableWAL(log); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	waitIncomplete(t, log, 0)
+	if triggered.Load() != 1 {
+		t.Fatalf("triggered: %d", triggered.Load())
+	}
+}
+
+func TestWALTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir, wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := log.Append(TestWALEntity{Name: "a"}, entity.Metadata{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if written, err := log.Append(1, entity.Metadata{ID: "2"}); written || err != nil {
+		t.Fatalf("unknown types must not be written: %v, %v", written, err)
+	}
+	_ = log.Close()
+
+	// simulate a write interrupted by a crash
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	file, err := os.OpenFile(segments[len(segments)-1], os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.Write([]byte{0, 0, 1, 0, 1, 2})
+	_ = file.Close()
+
+	log, err = wal.Open(dir, wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	incomplete := log.Incomplete()
+	if len(incomplete) != 1 || incomplete[0].Metadata.ID != "1" || incomplete[0].TypeName != "test.wal/v1" {
+		t.Fatalf("unexpected incomplete entries: %+v", incomplete)
+	}
+	event, err := log.Codec().Decode(incomplete[0].TypeName, incomplete[0].Payload)
+	if err != nil || event != (TestWALEntity{Name: "a"}) {
+		t.Fatalf("unexpected event: %v, %v", event, err)
+	}
+}
+
+func TestWALCorruptedLength(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir, wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := log.Append(TestWALEntity{Name: "a"}, entity.Metadata{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = log.Close()
+
+	// a header announcing a 4GB record must not be allocated
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	file, err := os.OpenFile(segments[len(segments)-1], os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 1, 2})
+	_ = file.Close()
+
+	log, err = wal.Open(dir, wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := log.Append(TestWALEntity{Name: "b"}, entity.Metadata{ID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = log.Close()
+
+	// the records written after the cut are kept
+	log, err = wal.Open(dir, wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	if incomplete := log.Incomplete(); len(incomplete) != 2 || incomplete[1].Metadata.ID != "2" {
+		t.Fatalf("unexpected incomplete entries: %+v", incomplete)
+	}
+}
+
+func TestWALRotationAndCompaction(t *testing.T) {
+	dir := t.TempDir()
+	segments := func() int {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+		return len(matches)
+	}
+
+	// every record starts a new segment
+	log, err := wal.Open(dir, wal.Options{Codec: newTestWALCodec(), SegmentSize: 1, SyncPolicy: wal.SyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if _, err := log.Append(TestWALEntity{Name: id}, entity.Metadata{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if segments() != 4 {
+		t.Fatalf("segments: %d", segments())
+	}
+
+	// the oldest segments are deleted once completed
+	_ = log.Complete("1")
+	if segments() != 4 {
+		t.Fatalf("segments: %d", segments())
+	}
+	// segment 2 holds an incomplete entry, so segment 3 (holding the completion of 1) must be kept
+	_ = log.Complete("3")
+	if segments() != 5 {
+		t.Fatalf("segments: %d", segments())
+	}
+
+	if err := log.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if segments() != 1 {
+		t.Fatalf("segments after compaction: %d", segments())
+	}
+	_ = log.Close()
+
+	log, err = wal.Open(dir, wal.Options{Codec: newTestWALCodec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	incomplete := log.Incomplete()
+	if len(incomplete) != 1 || incomplete[0].Metadata.ID != "2" {
+		t.Fatalf("unexpected incomplete entries: %+v", incomplete)
+	}
+}
@@ -0,0 +1,245 @@
+package test
+
+import (
+	gocontext "context"
+	"encoding/json"
+	stderrors "errors"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/signature"
+	"github.com/aivyss/eventx/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type TestWebhookEntity struct {
+	Name string `json:"name"`
+}
+
+func deliverWebhook(listener entity.EventListener[TestWebhookEntity], e TestWebhookEntity) error {
+	return listener.(entity.EnvelopeEventListener[TestWebhookEntity]).TriggerEnvelope(
+		gocontext.Background(),
+		entity.Envelope[TestWebhookEntity]{Metadata: entity.Metadata{ID: "webhook-1"}, Entity: e},
+	)
+}
+
+func TestWebhookListener(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	secret := []byte("secret")
+	received := make(chan TestWebhookEntity, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !signature.Verify(secret, body, r.Header.Get(signature.Header)) || r.Header.Get(webhook.EventIDHeader) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var e TestWebhookEntity
+		_ = json.Unmarshal(body, &e)
+		received <- e
+	}))
+	defer server.Close()
+
+	_ = eventx.RegisterEventListener(webhook.Listener[TestWebhookEntity](server.URL, webhook.Options{Secret: secret}))
+	ctxs, err := eventx.Trigger(TestWebhookEntity{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-received:
+		if e.Name != "a" {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not called")
+	}
+	waitFinished(t, ctxs)
+	if ctxs[0].Err() != nil {
+		t.Fatal(ctxs[0].Err())
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch attempts.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	// Retry-After is bounded by MaxBackoff
+	listener := webhook.Listener[TestWebhookEntity](server.URL, webhook.Options{
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+	startedAt := time.Now()
+	if err := deliverWebhook(listener, TestWebhookEntity{}); err != nil {
+		t.Fatal(err)
+	}
+	if attempts.Load() != 3 || time.Since(startedAt) > 500*time.Millisecond {
+		t.Fatalf("attempts: %d, elapsed: %v", attempts.Load(), time.Since(startedAt))
+	}
+}
+
+func TestWebhookPermanentFailure(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "bad event", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	listener := webhook.Listener[TestWebhookEntity](server.URL, webhook.Options{MinBackoff: time.Millisecond})
+	err := deliverWebhook(listener, TestWebhookEntity{})
+	statusErr, ok := err.(*webhook.StatusError)
+	if !ok || statusErr.StatusCode != http.StatusBadRequest || attempts.Load() != 1 {
+		t.Fatalf("unexpected error: %v, attempts: %d", err, attempts.Load())
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	// the timeout bounds the attempts of a custom client as well
+	listener := webhook.Listener[TestWebhookEntity](server.URL, webhook.Options{
+		Client:      &http.Client{},
+		Timeout:     20 * time.Millisecond,
+		MaxAttempts: 2,
+		MinBackoff:  time.Millisecond,
+	})
+	start := time.Now()
+	err := deliverWebhook(listener, TestWebhookEntity{})
+	if !stderrors.Is(err, gocontext.DeadlineExceeded) || attempts.Load() != 2 || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("the attempts must time out: %v, attempts: %d", err, attempts.Load())
+	}
+}
+
+func TestWebhookInvalidRequest(t *testing.T) {
+	listener := webhook.Listener[TestWebhookEntity]("http://invalid\x7f", webhook.Options{
+		MaxAttempts:      1,
+		FailureThreshold: 1,
+	})
+	for i := 0; i < 2; i++ {
+		// the request cannot be built, which neither opens nor closes the circuit
+		if err := deliverWebhook(listener, TestWebhookEntity{}); err == nil || err == errors.CircuitOpenErr {
+			t.Fatalf("the delivery must fail with the request: %v", err)
+		}
+	}
+}
+
+func TestWebhookCircuitBreaker(t *testing.T) {
+	var attempts atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	listener := webhook.Listener[TestWebhookEntity](server.URL, webhook.Options{
+		MaxAttempts:      1,
+		FailureThreshold: 2,
+		OpenTimeout:      30 * time.Millisecond,
+	})
+	for i := 0; i < 2; i++ {
+		if _, ok := deliverWebhook(listener, TestWebhookEntity{}).(*webhook.StatusError); !ok {
+			t.Fatal("the delivery must fail")
+		}
+	}
+	if err := deliverWebhook(listener, TestWebhookEntity{}); err != errors.CircuitOpenErr || attempts.Load() != 2 {
+		t.Fatalf("the circuit must be open: %v, attempts: %d", err, attempts.Load())
+	}
+
+	// a trial request closes the circuit
+	time.Sleep(40 * time.Millisecond)
+	healthy.Store(true)
+	if err := deliverWebhook(listener, TestWebhookEntity{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := deliverWebhook(listener, TestWebhookEntity{}); err != nil || attempts.Load() != 4 {
+		t.Fatalf("the circuit must be closed: %v, attempts: %d", err, attempts.Load())
+	}
+}
+
+func TestWebhookFailedTrial(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	listener := webhook.Listener[TestWebhookEntity](server.URL, webhook.Options{
+		MaxAttempts:      3,
+		MinBackoff:       time.Millisecond,
+		FailureThreshold: 1,
+		OpenTimeout:      30 * time.Millisecond,
+	})
+	if _, ok := deliverWebhook(listener, TestWebhookEntity{}).(*webhook.StatusError); !ok || attempts.Load() != 1 {
+		t.Fatalf("the delivery must fail with its own error, attempts: %d", attempts.Load())
+	}
+
+	// the failed trial reopens the circuit
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := deliverWebhook(listener, TestWebhookEntity{}).(*webhook.StatusError); !ok || attempts.Load() != 2 {
+		t.Fatalf("the trial must fail with its own error, attempts: %d", attempts.Load())
+	}
+	if err := deliverWebhook(listener, TestWebhookEntity{}); err != errors.CircuitOpenErr || attempts.Load() != 2 {
+		t.Fatalf("the circuit must be open: %v, attempts: %d", err, attempts.Load())
+	}
+}
+
+func TestWebhookClose(t *testing.T) {
+	eventx.RunDefaultApplication()
+	defer eventx.Close()
+
+	attempted := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempted <- struct{}{}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_ = eventx.RegisterEventListener(webhook.Listener[TestWebhookEntity](server.URL, webhook.Options{MinBackoff: time.Minute}))
+	ctxs, err := eventx.Trigger(TestWebhookEntity{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-attempted
+
+	closeContext, cancel := gocontext.WithTimeout(gocontext.Background(), 5*time.Second)
+	defer cancel()
+	startedAt := time.Now()
+	if err := eventx.CloseContext(closeContext); err != nil || time.Since(startedAt) > time.Second {
+		t.Fatalf("the retry must be canceled: %v, elapsed: %v", err, time.Since(startedAt))
+	}
+
+	waitFinished(t, ctxs)
+	var statusErr *webhook.StatusError
+	if !stderrors.As(ctxs[0].Err(), &statusErr) || !stderrors.Is(ctxs[0].Err(), gocontext.Canceled) {
+		t.Fatalf("unexpected error: %v", ctxs[0].Err())
+	}
+}
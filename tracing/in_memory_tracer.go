@@ -0,0 +1,173 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	eventxcontext "github.com/aivyss/eventx/context"
+	"sync"
+	"time"
+)
+
+// SpanContext
+//
+// Identifies a span recorded by InMemoryTracer.
+type SpanContext struct {
+	TraceID uint64
+	SpanID  uint64
+}
+
+// RecordedSpan
+//
+// A span recorded by InMemoryTracer.
+type RecordedSpan struct {
+	Name        string
+	SpanContext SpanContext
+	// Parent is nil for the root spans.
+	Parent *SpanContext
+	// Links holds the span contexts of the triggering contexts.
+	Links      []SpanContext
+	Attributes map[string]any
+	Outcome    eventxcontext.SpanOutcome
+	Err        error
+	Start      time.Time
+	End        time.Time
+	Ended      bool
+}
+
+// InMemoryTracer
+//
+// An eventx context.Tracer that keeps every span in memory. It is intended for tests.
+//
+// The span of a listener execution starts a new trace linked to the span of the triggering context,
+// and it becomes the span of the context passed to the listener, so the events triggered from the listener are linked to it.
+type InMemoryTracer struct {
+	lock   sync.Mutex
+	nextID uint64
+	spans  []*RecordedSpan
+}
+
+func NewInMemoryTracer() *InMemoryTracer {
+	return &InMemoryTracer{}
+}
+
+type spanContextKey struct{}
+
+// SpanContextFromContext
+//
+// Returns the span context carried by ctx.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	spanContext, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return spanContext, ok
+}
+
+// StartSpan
+//
+// Starts a span of the application itself, e.g. the span of the HTTP request that triggers events.
+// The span is a child of the span carried by ctx, if any. It ends when end is called.
+func (t *InMemoryTracer) StartSpan(ctx context.Context, name string) (context.Context, func(err error)) {
+	span := &RecordedSpan{
+		Name:       name,
+		Attributes: map[string]any{},
+		Start:      time.Now(),
+	}
+	id := t.newID()
+	span.SpanContext = SpanContext{TraceID: id, SpanID: id}
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		span.Parent = &parent
+		span.SpanContext.TraceID = parent.TraceID
+	}
+	t.add(span)
+
+	return context.WithValue(ctx, spanContextKey{}, span.SpanContext), func(err error) {
+		t.end(span, "", err)
+	}
+}
+
+// StartListenerSpan implements eventx context.Tracer.
+func (t *InMemoryTracer) StartListenerSpan(triggerContext context.Context, info eventxcontext.SpanInfo) (context.Context, eventxcontext.Span) {
+	span := &RecordedSpan{
+		Name: fmt.Sprintf("%s process", info.EventType),
+		Attributes: map[string]any{
+			"eventx.event_id":   info.EventID,
+			"eventx.event_type": info.EventType,
+			"eventx.listener":   info.Listener,
+			"eventx.attempt":    info.Attempt,
+		},
+		Start: time.Now(),
+	}
+	if link, ok := SpanContextFromContext(triggerContext); ok {
+		span.Links = []SpanContext{link}
+	}
+	id := t.newID()
+	span.SpanContext = SpanContext{TraceID: id, SpanID: id}
+	t.add(span)
+
+	return context.WithValue(triggerContext, spanContextKey{}, span.SpanContext), &inMemorySpan{tracer: t, span: span}
+}
+
+// Spans
+//
+// Returns copies of the recorded spans in start order.
+func (t *InMemoryTracer) Spans() []RecordedSpan {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	spans := make([]RecordedSpan, 0, len(t.spans))
+	for _, span := range t.spans {
+		copied := *span
+		copied.Attributes = make(map[string]any, len(span.Attributes))
+		for key, value := range span.Attributes {
+			copied.Attributes[key] = value
+		}
+		spans = append(spans, copied)
+	}
+
+	return spans
+}
+
+// Reset
+//
+// Discards the recorded spans.
+func (t *InMemoryTracer) Reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.spans = nil
+}
+
+func (t *InMemoryTracer) newID() uint64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.nextID += 1
+	return t.nextID
+}
+
+func (t *InMemoryTracer) add(span *RecordedSpan) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.spans = append(t.spans, span)
+}
+
+func (t *InMemoryTracer) end(span *RecordedSpan, outcome eventxcontext.SpanOutcome, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	span.Outcome = outcome
+	span.Err = err
+	span.End = time.Now()
+	span.Ended = true
+	if outcome != "" {
+		span.Attributes["eventx.outcome"] = string(outcome)
+	}
+}
+
+type inMemorySpan struct {
+	tracer *InMemoryTracer
+	span   *RecordedSpan
+}
+
+func (s *inMemorySpan) End(outcome eventxcontext.SpanOutcome, err error) {
+	s.tracer.end(s.span, outcome, err)
+}
@@ -0,0 +1,82 @@
+// Package oteltracing adapts an OpenTelemetry trace.Tracer to the eventx context.Tracer.
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+	eventxcontext "github.com/aivyss/eventx/context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	AttributeEventID   = attribute.Key("eventx.event_id")
+	AttributeEventType = attribute.Key("eventx.event_type")
+	AttributeListener  = attribute.Key("eventx.listener")
+	AttributeAttempt   = attribute.Key("eventx.attempt")
+	AttributeOutcome   = attribute.Key("eventx.outcome")
+)
+
+type Option func(t *tracer)
+
+// WithChildSpans
+//
+// Makes the listener spans children of the span of the triggering context
+// instead of new traces linked to it.
+func WithChildSpans() Option {
+	return func(t *tracer) {
+		t.childSpans = true
+	}
+}
+
+// NewTracer
+//
+// Returns an eventx context.Tracer that starts a consumer span with the given trace.Tracer for every listener execution.
+// By default, the span starts a new trace linked to the span of the triggering context.
+func NewTracer(otelTracer trace.Tracer, opts ...Option) eventxcontext.Tracer {
+	t := &tracer{tracer: otelTracer}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+type tracer struct {
+	tracer     trace.Tracer
+	childSpans bool
+}
+
+func (t *tracer) StartListenerSpan(triggerContext context.Context, info eventxcontext.SpanInfo) (context.Context, eventxcontext.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			AttributeEventID.String(info.EventID),
+			AttributeEventType.String(info.EventType),
+			AttributeListener.String(info.Listener),
+			AttributeAttempt.Int(info.Attempt),
+		),
+	}
+
+	if link := trace.SpanContextFromContext(triggerContext); link.IsValid() && !t.childSpans {
+		opts = append(opts, trace.WithNewRoot(), trace.WithLinks(trace.Link{SpanContext: link}))
+	}
+
+	ctx, span := t.tracer.Start(triggerContext, fmt.Sprintf("%s process", info.EventType), opts...)
+
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) End(outcome eventxcontext.SpanOutcome, err error) {
+	s.span.SetAttributes(AttributeOutcome.String(string(outcome)))
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,95 @@
+package oteltracing_test
+
+import (
+	"context"
+	"errors"
+	eventxcontext "github.com/aivyss/eventx/context"
+	"github.com/aivyss/eventx/tracing/oteltracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func newRecorder() (*tracetest.SpanRecorder, trace.Tracer) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	return recorder, provider.Tracer("test")
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+
+	return values
+}
+
+func TestTracer(t *testing.T) {
+	recorder, otelTracer := newRecorder()
+	tracer := oteltracing.NewTracer(otelTracer)
+
+	triggerContext, request := otelTracer.Start(context.Background(), "request")
+	ctx, span := tracer.StartListenerSpan(triggerContext, eventxcontext.SpanInfo{
+		EventID:   "1",
+		EventType: "orders.OrderPlaced",
+		Listener:  "orders.OrderPlaced#0",
+		Attempt:   1,
+	})
+	span.End(eventxcontext.SpanOutcomeFailed, errors.New("failed"))
+	request.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("unexpected spans: %d", len(ended))
+	}
+	listener := ended[0]
+
+	if listener.Name() != "orders.OrderPlaced process" || listener.SpanKind() != trace.SpanKindConsumer {
+		t.Fatalf("unexpected span: %s %s", listener.Name(), listener.SpanKind())
+	}
+	values := attributes(listener)
+	if values[oteltracing.AttributeEventID].AsString() != "1" ||
+		values[oteltracing.AttributeEventType].AsString() != "orders.OrderPlaced" ||
+		values[oteltracing.AttributeListener].AsString() != "orders.OrderPlaced#0" ||
+		values[oteltracing.AttributeAttempt].AsInt64() != 1 ||
+		values[oteltracing.AttributeOutcome].AsString() != string(eventxcontext.SpanOutcomeFailed) {
+		t.Fatalf("unexpected attributes: %v", listener.Attributes())
+	}
+	if listener.Status().Code != codes.Error || len(listener.Events()) != 1 {
+		t.Fatalf("the error is not recorded: %+v", listener.Status())
+	}
+
+	// a new trace linked to the span of the trigger
+	if listener.Parent().IsValid() || listener.SpanContext().TraceID() == request.SpanContext().TraceID() {
+		t.Fatal("the listener span must start a new trace")
+	}
+	if links := listener.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != request.SpanContext().SpanID() {
+		t.Fatalf("the listener span is not linked to the request span: %+v", links)
+	}
+	if trace.SpanContextFromContext(ctx).SpanID() != listener.SpanContext().SpanID() {
+		t.Fatal("the context must carry the listener span")
+	}
+}
+
+func TestTracerChildSpans(t *testing.T) {
+	recorder, otelTracer := newRecorder()
+	tracer := oteltracing.NewTracer(otelTracer, oteltracing.WithChildSpans())
+
+	triggerContext, request := otelTracer.Start(context.Background(), "request")
+	_, span := tracer.StartListenerSpan(triggerContext, eventxcontext.SpanInfo{EventType: "orders.OrderPlaced", Attempt: 1})
+	span.End(eventxcontext.SpanOutcomeSucceeded, nil)
+	request.End()
+
+	listener := recorder.Ended()[0]
+	if listener.Parent().SpanID() != request.SpanContext().SpanID() || len(listener.Links()) != 0 {
+		t.Fatalf("the listener span must be a child of the request span: %+v", listener.Parent())
+	}
+	if listener.Status().Code == codes.Error {
+		t.Fatalf("unexpected status: %+v", listener.Status())
+	}
+}
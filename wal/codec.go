@@ -0,0 +1,11 @@
+package wal
+
+// Codec
+//
+// Serializes the events written to the Log.
+// Encode returns errors.UnknownEventTypeErr for the events it does not handle; they are not written.
+// (See codec.Registry)
+type Codec interface {
+	Encode(event any) (typeName string, payload []byte, err error)
+	Decode(typeName string, payload []byte) (any, error)
+}
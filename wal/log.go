@@ -0,0 +1,527 @@
+package wal
+
+import (
+	stderrors "errors"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSegmentSize  int64 = 64 << 20
+	DefaultSyncInterval       = time.Second
+)
+
+// SyncPolicy
+//
+// Decides when the written records are flushed to the disk with fsync.
+type SyncPolicy int
+
+const (
+	// SyncEveryWrite flushes every record before Append and Complete return. It is the default.
+	SyncEveryWrite SyncPolicy = iota
+	// SyncPeriodically flushes the records every Options.SyncInterval.
+	// The records written after the last flush can be lost on a crash of the machine.
+	SyncPeriodically
+	// SyncNever leaves the flush to the operating system.
+	SyncNever
+)
+
+type Options struct {
+	// Codec serializes the events. It is required.
+	Codec Codec
+	// SegmentSize is the size a segment is rotated at. It is DefaultSegmentSize when it is 0.
+	SegmentSize int64
+	SyncPolicy  SyncPolicy
+	// SyncInterval is used with SyncPeriodically. It is DefaultSyncInterval when it is 0.
+	SyncInterval time.Duration
+	// CompactInterval is the period of Compact. The log is not compacted periodically when it is 0.
+	CompactInterval time.Duration
+}
+
+// Entry
+//
+// An event written to the log that has not been completed yet.
+type Entry struct {
+	TypeName string
+	Payload  []byte
+	Metadata entity.Metadata
+	// Sequence is the position of the entry in the log. It is kept when the log is compacted or reopened.
+	Sequence uint64
+	// segment is the sequence number of the segment holding the append record.
+	segment uint64
+}
+
+// Log
+//
+// A file-backed write-ahead log of triggered events.
+//
+// Events are appended before they are acknowledged and completed after their listeners have succeeded.
+// The log is made of segments; a new segment is started when the active one exceeds Options.SegmentSize.
+// The oldest segments are deleted once all of their entries have been completed.
+//
+// If a write fails, the partial record is cut off and the log refuses the following writes until it is reopened.
+type Log struct {
+	lock    sync.Mutex
+	dir     string
+	options Options
+	closed  bool
+	// active is the segment the records are written to.
+	active     *os.File
+	activeSeq  uint64
+	activeSize int64
+	dirty      bool
+	// failed is the error of the failed write.
+	failed error
+	// sealed holds the sequence numbers of the older segments in ascending order.
+	sealed []uint64
+	// pending holds the incomplete entries by event ID.
+	pending map[string]*Entry
+	// segmentPending counts the incomplete entries of each segment.
+	segmentPending map[uint64]int
+	// sequence is the last Sequence given to an entry.
+	sequence uint64
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Open
+//
+// Opens the log in the directory (it is created if it does not exist) and loads its incomplete entries.
+// A torn record at the end of the last segment (an interrupted write) is discarded.
+func Open(dir string, options Options) (*Log, error) {
+	if options.Codec == nil || options.SegmentSize < 0 || options.SyncInterval < 0 || options.CompactInterval < 0 {
+		return nil, errors.InvalidConfigurationErr
+	}
+	if options.SegmentSize == 0 {
+		options.SegmentSize = DefaultSegmentSize
+	}
+	if options.SyncInterval == 0 {
+		options.SyncInterval = DefaultSyncInterval
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	l := &Log{
+		dir:            dir,
+		options:        options,
+		pending:        map[string]*Entry{},
+		segmentPending: map[uint64]int{},
+		stop:           make(chan struct{}),
+	}
+
+	seqs, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, seq := range seqs {
+		records, validSize, torn, err := readSegment(segmentPath(dir, seq))
+		if err != nil {
+			return nil, err
+		}
+		if torn {
+			if i != len(seqs)-1 {
+				return nil, errors.CorruptedLogErr
+			}
+			if err := os.Truncate(segmentPath(dir, seq), validSize); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, r := range records {
+			l.load(seq, r)
+		}
+	}
+
+	var lastSeq uint64
+	if len(seqs) > 0 {
+		lastSeq = seqs[len(seqs)-1]
+	}
+	if err := l.openSegment(lastSeq + 1); err != nil {
+		return nil, err
+	}
+	l.sealed = seqs
+	l.trimSegments()
+
+	if options.SyncPolicy == SyncPeriodically {
+		l.every(options.SyncInterval, func() {
+			l.lock.Lock()
+			defer l.lock.Unlock()
+			_ = l.sync()
+		})
+	}
+	if options.CompactInterval > 0 {
+		l.every(options.CompactInterval, func() {
+			_ = l.Compact()
+		})
+	}
+
+	return l, nil
+}
+
+// Codec
+//
+// Returns the Codec of the log.
+func (l *Log) Codec() Codec {
+	return l.options.Codec
+}
+
+// Append
+//
+// Writes the event to the log.
+// It returns false without an error if the Codec does not handle the type of the event.
+func (l *Log) Append(event any, metadata entity.Metadata) (bool, error) {
+	typeName, payload, err := l.options.Codec.Encode(event)
+	if stderrors.Is(err, errors.UnknownEventTypeErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return false, errors.LogClosedErr
+	}
+	if l.failed != nil {
+		return false, l.failed
+	}
+	if _, ok := l.pending[metadata.ID]; ok {
+		// already written (e.g. replayed)
+		return true, nil
+	}
+
+	entry := &Entry{TypeName: typeName, Payload: payload, Metadata: metadata}
+	if err := l.writeAppend(entry); err != nil {
+		return false, err
+	}
+	if err := l.syncIfRequired(); err != nil {
+		return false, err
+	}
+
+	return true, l.rotateIfRequired()
+}
+
+// Complete
+//
+// Marks the event as completed. Completed events are not replayed.
+func (l *Log) Complete(eventID string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return errors.LogClosedErr
+	}
+	if l.failed != nil {
+		return l.failed
+	}
+
+	entry, ok := l.pending[eventID]
+	if !ok {
+		return nil
+	}
+
+	if err := l.write(record{Kind: recordComplete, ID: eventID}); err != nil {
+		return err
+	}
+	if err := l.syncIfRequired(); err != nil {
+		return err
+	}
+
+	delete(l.pending, eventID)
+	l.segmentPending[entry.segment] -= 1
+	l.trimSegments()
+
+	return l.rotateIfRequired()
+}
+
+// Incomplete
+//
+// Returns the entries that have not been completed, in the order they have been appended.
+func (l *Log) Incomplete() []Entry {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	entries := make([]Entry, 0, len(l.pending))
+	for _, entry := range l.pending {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Sequence < entries[j].Sequence
+	})
+
+	return entries
+}
+
+// Compact
+//
+// Rewrites the incomplete entries of the older segments into a new segment and deletes the older segments.
+func (l *Log) Compact() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return errors.LogClosedErr
+	}
+	if l.failed != nil {
+		return l.failed
+	}
+
+	if err := l.rotate(); err != nil {
+		return err
+	}
+
+	entries := make([]*Entry, 0, len(l.pending))
+	for _, entry := range l.pending {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Sequence < entries[j].Sequence
+	})
+
+	for _, entry := range entries {
+		l.segmentPending[entry.segment] -= 1
+		if err := l.writeAppend(entry); err != nil {
+			return err
+		}
+	}
+	if err := l.sync(); err != nil {
+		return err
+	}
+
+	for _, seq := range l.sealed {
+		l.removeSegment(seq)
+	}
+	l.sealed = nil
+
+	return nil
+}
+
+// Close
+//
+// Flushes and closes the log.
+func (l *Log) Close() error {
+	l.lock.Lock()
+	if l.closed {
+		l.lock.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.stop)
+	l.lock.Unlock()
+
+	l.wg.Wait()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if err := l.sync(); err != nil {
+		return err
+	}
+
+	return l.active.Close()
+}
+
+// load
+//
+// Applies a record read at Open.
+func (l *Log) load(seq uint64, r record) {
+	switch r.Kind {
+	case recordAppend:
+		if r.Metadata == nil {
+			return
+		}
+		if prev, ok := l.pending[r.ID]; ok {
+			// rewritten by Compact
+			l.segmentPending[prev.segment] -= 1
+		}
+
+		sequence := r.Sequence
+		if sequence == 0 {
+			// written without its sequence
+			sequence = l.sequence + 1
+		}
+		l.sequence = max(l.sequence, sequence)
+		l.pending[r.ID] = &Entry{
+			TypeName: r.TypeName,
+			Payload:  r.Payload,
+			Metadata: *r.Metadata,
+			Sequence: sequence,
+			segment:  seq,
+		}
+		l.segmentPending[seq] += 1
+	case recordComplete:
+		if entry, ok := l.pending[r.ID]; ok {
+			delete(l.pending, r.ID)
+			l.segmentPending[entry.segment] -= 1
+		}
+	}
+}
+
+func (l *Log) writeAppend(entry *Entry) error {
+	metadata := entry.Metadata
+	sequence := entry.Sequence
+	if sequence == 0 {
+		sequence = l.sequence + 1
+	}
+	err := l.write(record{
+		Kind:     recordAppend,
+		ID:       metadata.ID,
+		TypeName: entry.TypeName,
+		Metadata: &metadata,
+		Payload:  entry.Payload,
+		Sequence: sequence,
+	})
+	if err != nil {
+		return err
+	}
+
+	if entry.Sequence == 0 {
+		l.sequence = sequence
+		entry.Sequence = sequence
+	}
+	entry.segment = l.activeSeq
+	l.pending[metadata.ID] = entry
+	l.segmentPending[l.activeSeq] += 1
+
+	return nil
+}
+
+func (l *Log) write(r record) error {
+	frame, err := encodeFrame(r)
+	if err != nil {
+		return err
+	}
+
+	l.dirty = true
+	if _, err := l.active.Write(frame); err != nil {
+		return l.fail(err)
+	}
+	l.activeSize += int64(len(frame))
+
+	return nil
+}
+
+// fail
+//
+// Cuts the partial record off the active segment and refuses the following writes,
+// so that no record is written after a torn one, which would be lost when the log is reopened.
+func (l *Log) fail(err error) error {
+	l.failed = stderrors.Join(errors.CorruptedLogErr, err)
+	if truncateErr := l.active.Truncate(l.activeSize); truncateErr != nil {
+		return stderrors.Join(err, truncateErr)
+	}
+
+	return err
+}
+
+func (l *Log) syncIfRequired() error {
+	if l.options.SyncPolicy != SyncEveryWrite {
+		return nil
+	}
+
+	return l.sync()
+}
+
+func (l *Log) sync() error {
+	if !l.dirty {
+		return nil
+	}
+
+	if err := l.active.Sync(); err != nil {
+		// the records written since the last flush may be lost
+		l.failed = stderrors.Join(errors.CorruptedLogErr, err)
+		return err
+	}
+	l.dirty = false
+
+	return nil
+}
+
+func (l *Log) rotateIfRequired() error {
+	if l.activeSize < l.options.SegmentSize {
+		return nil
+	}
+
+	return l.rotate()
+}
+
+// rotate
+//
+// Closes the active segment and starts a new one.
+func (l *Log) rotate() error {
+	if err := l.sync(); err != nil {
+		return err
+	}
+	if err := l.active.Close(); err != nil {
+		return err
+	}
+
+	prev := l.activeSeq
+	if err := l.openSegment(prev + 1); err != nil {
+		return err
+	}
+	l.sealed = append(l.sealed, prev)
+	l.trimSegments()
+
+	return nil
+}
+
+func (l *Log) openSegment(seq uint64) error {
+	file, err := os.OpenFile(segmentPath(l.dir, seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	l.active = file
+	l.activeSeq = seq
+	l.activeSize = 0
+
+	return nil
+}
+
+// trimSegments
+//
+// Deletes the oldest segments as long as they have no incomplete entries.
+// Only the oldest ones can be deleted: a segment may hold the completion records of the entries of the older segments.
+func (l *Log) trimSegments() {
+	for len(l.sealed) > 0 && l.segmentPending[l.sealed[0]] == 0 {
+		l.removeSegment(l.sealed[0])
+		l.sealed = l.sealed[1:]
+	}
+}
+
+func (l *Log) removeSegment(seq uint64) {
+	_ = os.Remove(segmentPath(l.dir, seq))
+	delete(l.segmentPending, seq)
+}
+
+// every
+//
+// Runs f periodically until the log is closed.
+func (l *Log) every(interval time.Duration, f func()) {
+	l.wg.Add(1)
+
+	go func() {
+		defer l.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				f()
+			}
+		}
+	}()
+}
@@ -0,0 +1,113 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/internal/frame"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	segmentSuffix = ".wal"
+
+	recordAppend   = "a"
+	recordComplete = "c"
+)
+
+// record
+//
+// A line of the log: an appended event or the completion of an event.
+type record struct {
+	Kind     string           `json:"k"`
+	ID       string           `json:"i"`
+	TypeName string           `json:"t,omitempty"`
+	Metadata *entity.Metadata `json:"m,omitempty"`
+	Payload  []byte           `json:"p,omitempty"`
+	Sequence uint64           `json:"s,omitempty"`
+}
+
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%020d%s", seq, segmentSuffix)
+}
+
+// listSegments
+//
+// Returns the sequence numbers of the segments in the directory in ascending order.
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqs []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	return seqs, nil
+}
+
+// encodeFrame
+//
+// Frames a record as JSON. (See frame.Encode)
+func encodeFrame(r record) ([]byte, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return frame.Encode(body)
+}
+
+// readSegment
+//
+// Reads the records of a segment.
+// Reading stops at the first incomplete or corrupted frame (a torn write), and torn is true in that case.
+// validSize is the size of the segment up to the last valid frame.
+func readSegment(path string) (records []record, validSize int64, torn bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		body, err := frame.Read(reader)
+		if err == io.EOF {
+			return records, validSize, false, nil
+		}
+		if err != nil {
+			return records, validSize, true, nil
+		}
+
+		var r record
+		if err := json.Unmarshal(body, &r); err != nil {
+			return records, validSize, true, nil
+		}
+
+		records = append(records, r)
+		validSize += int64(frame.HeaderSize + len(body))
+	}
+}
+
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, segmentName(seq))
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker
+//
+// A circuit breaker: it opens after threshold consecutive failures and lets a trial request through after openTimeout.
+type breaker struct {
+	lock        sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	state       breakerState
+	failures    int
+	openedAt    time.Time
+}
+
+// allow
+//
+// Reports whether a request can be sent. In the half-open state, only one trial request is allowed at a time.
+func (b *breaker) allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *breaker) succeed() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+// release
+//
+// Ends a trial request without an outcome, so that the next request becomes the trial.
+func (b *breaker) release() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+func (b *breaker) fail(now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.failures += 1
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = now
+	}
+}
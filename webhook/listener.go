@@ -0,0 +1,269 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"github.com/aivyss/eventx"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/signature"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultTimeout          = 10 * time.Second
+	DefaultMaxAttempts      = 5
+	DefaultMinBackoff       = 500 * time.Millisecond
+	DefaultMaxBackoff       = 30 * time.Second
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+
+	EventIDHeader       = "X-Eventx-Event-Id"
+	EventTypeHeader     = "X-Eventx-Event-Type"
+	CorrelationIDHeader = "X-Eventx-Correlation-Id"
+)
+
+type Options struct {
+	// Secret enables the HMAC-SHA256 signature of the body in signature.Header.
+	Secret []byte
+	// Client sends the requests. It is http.DefaultClient when it is nil.
+	Client *http.Client
+	// Timeout bounds every attempt, whatever the Client is. It is DefaultTimeout when it is 0.
+	Timeout time.Duration
+	// EventType is sent in EventTypeHeader. It is the name of the Go type when it is blank.
+	EventType string
+	// Headers are added to every request.
+	Headers map[string]string
+	// MaxAttempts is the number of the attempts per event. It is DefaultMaxAttempts when it is 0.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the delay between the attempts, which doubles after every failure.
+	// A Retry-After response overrides the delay, up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// FailureThreshold is the number of the consecutive retryable failures opening the circuit. It is DefaultFailureThreshold when it is 0.
+	FailureThreshold int
+	// OpenTimeout is the time the circuit stays open before a trial request. It is DefaultOpenTimeout when it is 0.
+	OpenTimeout time.Duration
+}
+
+// StatusError
+//
+// The error of a delivery rejected by the endpoint.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook responded %d: %s", e.StatusCode, e.Body)
+}
+
+// Listener
+//
+// Returns an event listener POSTing the events as JSON to the url.
+//
+// The ID, the type and the correlation ID of the event are sent in the headers, and the body is signed with Options.Secret.
+// The network errors, 408, 429 and 5xx responses are retried with an exponential backoff (or after Retry-After);
+// the other responses fail the event with *StatusError.
+// After Options.FailureThreshold consecutive retryable failures the circuit opens, and the events fail with errors.CircuitOpenErr
+// without a request until Options.OpenTimeout passes.
+//
+// The retries are waited on the worker processing the event, and they are canceled once the application starts closing.
+// (See eventx.Closing)
+func Listener[E any](url string, options Options) entity.EventListener[E] {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.EventType == "" {
+		options.EventType = reflect.TypeOf((*E)(nil)).Elem().String()
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+	if options.MinBackoff <= 0 {
+		options.MinBackoff = DefaultMinBackoff
+	}
+	if options.MaxBackoff < options.MinBackoff {
+		options.MaxBackoff = max(DefaultMaxBackoff, options.MinBackoff)
+	}
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = DefaultFailureThreshold
+	}
+	if options.OpenTimeout <= 0 {
+		options.OpenTimeout = DefaultOpenTimeout
+	}
+
+	return &webhookListener[E]{
+		url:     url,
+		options: options,
+		breaker: &breaker{threshold: options.FailureThreshold, openTimeout: options.OpenTimeout},
+	}
+}
+
+type webhookListener[E any] struct {
+	url     string
+	options Options
+	breaker *breaker
+}
+
+func (l *webhookListener[E]) Trigger(e E) error {
+	return l.TriggerEnvelope(context.Background(), entity.Envelope[E]{Entity: e})
+}
+
+func (l *webhookListener[E]) TriggerEnvelope(ctx context.Context, envelope entity.Envelope[E]) error {
+	body, err := json.Marshal(envelope.Entity)
+	if err != nil {
+		return err
+	}
+
+	// the retries are canceled once the application starts closing, so that they do not hold its drain
+	retryContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-eventx.Closing():
+			cancel()
+		case <-retryContext.Done():
+		}
+	}()
+
+	backoff := l.options.MinBackoff
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		attemptContext := ctx
+		if attempt > 1 {
+			attemptContext = retryContext
+		}
+		timeoutContext, cancelAttempt := context.WithTimeout(attemptContext, l.options.Timeout)
+		request, err := l.newRequest(timeoutContext, envelope.Metadata, body)
+		if err != nil {
+			// a request which cannot be built tells nothing about the endpoint
+			cancelAttempt()
+			return err
+		}
+
+		if !l.breaker.allow(time.Now()) {
+			cancelAttempt()
+			if lastErr != nil {
+				// the circuit has been opened by this delivery
+				return lastErr
+			}
+			return errors.CircuitOpenErr
+		}
+
+		retryAfter, err := l.send(request)
+		cancelAttempt()
+		if err == nil {
+			l.breaker.succeed()
+			return nil
+		}
+		if retryAfter < 0 {
+			// the endpoint is up, the event is rejected
+			l.breaker.succeed()
+			return err
+		}
+		if attemptContext.Err() != nil {
+			// the attempt has been canceled, which tells nothing about the endpoint
+			l.breaker.release()
+			return stderrors.Join(lastErr, err)
+		}
+		l.breaker.fail(time.Now())
+		lastErr = err
+
+		if attempt >= l.options.MaxAttempts {
+			return err
+		}
+
+		delay := backoff
+		if retryAfter > 0 {
+			delay = min(retryAfter, l.options.MaxBackoff)
+		}
+		select {
+		case <-retryContext.Done():
+			return stderrors.Join(err, retryContext.Err())
+		case <-time.After(delay):
+		}
+		backoff = min(backoff*2, l.options.MaxBackoff)
+	}
+}
+
+// newRequest
+//
+// Builds the request of an attempt.
+func (l *webhookListener[E]) newRequest(ctx context.Context, metadata entity.Metadata, body []byte) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range l.options.Headers {
+		request.Header.Set(key, value)
+	}
+	request.Header.Set(EventTypeHeader, l.options.EventType)
+	if metadata.ID != "" {
+		request.Header.Set(EventIDHeader, metadata.ID)
+	}
+	if metadata.CorrelationID != "" {
+		request.Header.Set(CorrelationIDHeader, metadata.CorrelationID)
+	}
+	if l.options.Secret != nil {
+		request.Header.Set(signature.Header, signature.Sign(l.options.Secret, body))
+	}
+
+	return request, nil
+}
+
+// send
+//
+// Sends an attempt. On a failure, retryAfter is negative if the failure must not be retried,
+// positive if the endpoint has asked for a delay, and 0 otherwise.
+func (l *webhookListener[E]) send(request *http.Request) (retryAfter time.Duration, err error) {
+	response, err := l.options.Client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return 0, nil
+	}
+
+	err = &StatusError{StatusCode: response.StatusCode, Body: string(responseBody)}
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests,
+		response.StatusCode == http.StatusRequestTimeout,
+		response.StatusCode >= 500:
+		return parseRetryAfter(response.Header.Get("Retry-After"), time.Now()), err
+	default:
+		return -1, err
+	}
+}
+
+// parseRetryAfter
+//
+// Parses the Retry-After header: a number of seconds or an HTTP date. It returns 0 if the header is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
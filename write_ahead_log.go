@@ -0,0 +1,137 @@
+package eventx
+
+import (
+	gocontext "context"
+	stderrors "errors"
+	"github.com/aivyss/eventx/context"
+	"github.com/aivyss/eventx/entity"
+	"github.com/aivyss/eventx/errors"
+	"github.com/aivyss/eventx/wal"
+	"sync"
+)
+
+var (
+	writeAheadLog     *wal.Log
+	writeAheadLogLock sync.RWMutex
+	// writeAheadInFlight holds the IDs of the entries being processed by writeAheadApp,
+	// which are not replayed again. It is reset when the entries of another application are tracked.
+	writeAheadApp      *context.ApplicationContext
+	writeAheadInFlight map[string]struct{}
+)
+
+// EnableWAL
+//
+// Writes every triggered event whose type is handled by the codec of the log ahead,
+// before Trigger returns, and marks it complete after all of its listeners have succeeded.
+//
+// The incomplete entries of the log (the events not completed before a crash) are replayed into the running application,
+// except the ones still being processed by it. Enabling the log which is already enabled does nothing.
+// The errors of the entries which could not be replayed are joined and returned; those entries stay incomplete.
+func EnableWAL(log *wal.Log) error {
+	if log == nil {
+		return errors.InvalidConfigurationErr
+	}
+	app := currentApplicationContext()
+	if app == nil {
+		return errors.ApplicationNotRunningErr
+	}
+
+	writeAheadLogLock.Lock()
+	if writeAheadLog == log {
+		writeAheadLogLock.Unlock()
+		return nil
+	}
+	writeAheadLog = log
+	writeAheadLogLock.Unlock()
+
+	var errs []error
+	for _, entry := range log.Incomplete() {
+		if !trackWriteAhead(app, entry.Metadata.ID) {
+			// in flight
+			continue
+		}
+
+		event, err := log.Codec().Decode(entry.TypeName, entry.Payload)
+		if err != nil {
+			untrackWriteAhead(app, entry.Metadata.ID)
+			errs = append(errs, err)
+			continue
+		}
+
+		ctxs, err := triggerEvent(gocontext.Background(), event, entry.Metadata, false)
+		if err != nil {
+			untrackWriteAhead(app, entry.Metadata.ID)
+			errs = append(errs, err)
+			continue
+		}
+		completeWhenSucceeded(app, log, entry.Metadata.ID, ctxs)
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// DisableWAL
+//
+// Stops writing the triggered events ahead. The log is not closed.
+func DisableWAL() {
+	writeAheadLogLock.Lock()
+	defer writeAheadLogLock.Unlock()
+
+	writeAheadLog = nil
+}
+
+func currentWriteAheadLog() *wal.Log {
+	writeAheadLogLock.RLock()
+	defer writeAheadLogLock.RUnlock()
+
+	return writeAheadLog
+}
+
+// trackWriteAhead
+//
+// Marks the entry as being processed by the application. It returns false if it already is.
+func trackWriteAhead(app *context.ApplicationContext, eventID string) bool {
+	writeAheadLogLock.Lock()
+	defer writeAheadLogLock.Unlock()
+
+	if writeAheadApp != app {
+		writeAheadApp = app
+		writeAheadInFlight = map[string]struct{}{}
+	}
+	if _, ok := writeAheadInFlight[eventID]; ok {
+		return false
+	}
+	writeAheadInFlight[eventID] = struct{}{}
+
+	return true
+}
+
+func untrackWriteAhead(app *context.ApplicationContext, eventID string) {
+	writeAheadLogLock.Lock()
+	defer writeAheadLogLock.Unlock()
+
+	if writeAheadApp == app {
+		delete(writeAheadInFlight, eventID)
+	}
+}
+
+// completeWhenSucceeded
+//
+// Completes the entry of the log once every event set of the event has finished without an error.
+// Canceled event sets count as succeeded. Failed or dropped events stay incomplete and are replayed by the next EnableWAL.
+func completeWhenSucceeded(app *context.ApplicationContext, log *wal.Log, eventID string, ctxs []entity.EventContext) {
+	go func() {
+		defer untrackWriteAhead(app, eventID)
+
+		for _, ctx := range ctxs {
+			<-ctx.Finished()
+		}
+		for _, ctx := range ctxs {
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		_ = log.Complete(eventID)
+	}()
+}